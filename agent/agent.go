@@ -2,9 +2,16 @@ package main
 
 import (
 	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
 	"net/url"
 	"os"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/pkg/errors"
@@ -12,7 +19,7 @@ import (
 	"github.com/shellhub-io/shellhub/agent/pkg/sysinfo"
 	"github.com/shellhub-io/shellhub/pkg/api/client"
 	"github.com/shellhub-io/shellhub/pkg/models"
-	"github.com/shellhub-io/shellhub/pkg/revdial"
+	"github.com/sirupsen/logrus"
 )
 
 type Agent struct {
@@ -25,6 +32,11 @@ type Agent struct {
 	serverInfo    *models.Info
 	serverAddress *url.URL
 	sessions      []string
+
+	mu              sync.Mutex // guards authData and the fields below
+	connectedSince  time.Time
+	lastUpdateCheck time.Time
+	latestVersion   string
 }
 
 func NewAgent(opts *ConfigOptions) (*Agent, error) {
@@ -37,12 +49,80 @@ func NewAgent(opts *ConfigOptions) (*Agent, error) {
 
 	a.serverAddress = serverAddress
 
+	clientOpts := []client.Opt{client.WithURL(serverAddress)}
+
+	if opts.Proxy != "" {
+		proxy, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid proxy address")
+		}
+
+		clientOpts = append(clientOpts, client.WithProxy(proxy, opts.NoProxy))
+	}
+
+	if opts.CACertificates != "" || opts.ProxyCA != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || opts.CACertificates != "" {
+			pool = x509.NewCertPool()
+		}
+
+		for _, path := range []string{opts.CACertificates, opts.ProxyCA} {
+			if path == "" {
+				continue
+			}
+
+			if err := appendCertsFromFile(pool, path); err != nil {
+				return nil, errors.Wrapf(err, "failed to load CA certificates from %s", path)
+			}
+		}
+
+		clientOpts = append(clientOpts, client.WithRootCAs(pool))
+	}
+
+	if opts.ServerPublicKeyPins != "" {
+		var pins [][]byte
+		for _, value := range strings.Split(opts.ServerPublicKeyPins, ",") {
+			pin, err := client.ParsePublicKeyPin(strings.TrimSpace(value))
+			if err != nil {
+				return nil, err
+			}
+
+			pins = append(pins, pin)
+		}
+
+		clientOpts = append(clientOpts, client.WithPublicKeyPins(pins))
+	}
+
+	if opts.ClientCertificate != "" || opts.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertificate, opts.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load client certificate")
+		}
+
+		clientOpts = append(clientOpts, client.WithClientCertificate(cert))
+	}
+
 	return &Agent{
 		opts: opts,
-		cli:  client.NewClient(client.WithURL(serverAddress)),
+		cli:  client.NewClient(clientOpts...),
 	}, nil
 }
 
+// appendCertsFromFile appends the certificates of the PEM bundle at path to
+// the pool.
+func appendCertsFromFile(pool *x509.CertPool, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		return errors.New("no certificates found")
+	}
+
+	return nil
+}
+
 // initialize initializes agent
 func (a *Agent) initialize() error {
 	if err := a.generateDeviceIdentity(); err != nil {
@@ -110,7 +190,7 @@ func (a *Agent) loadDeviceInfo() error {
 		return nil
 	}
 
-	a.Info = &models.DeviceInfo{
+	info := &models.DeviceInfo{
 		ID:         osrelease.ID,
 		PrettyName: osrelease.Name,
 		Version:    AgentVersion,
@@ -118,17 +198,104 @@ func (a *Agent) loadDeviceInfo() error {
 		Platform:   AgentPlatform,
 	}
 
+	loadDeviceInventory(info)
+
+	a.Info = info
+
 	return nil
 }
 
-// checkUpdate check for agent updates
-func (a *Agent) checkUpdate() (*semver.Version, error) {
+// loadDeviceInventory fills info with the inventory of the host. Failures are
+// only logged since the inventory is informative.
+func loadDeviceInventory(info *models.DeviceInfo) {
+	logError := func(what string, err error) {
+		logrus.WithFields(logrus.Fields{"err": err}).Debugf("Failed to read %s", what)
+	}
+
+	if hostname, err := sysinfo.Hostname(); err == nil {
+		info.Hostname = hostname
+	} else {
+		logError("hostname", err)
+	}
+
+	if version, err := sysinfo.KernelVersion(); err == nil {
+		info.KernelVersion = version
+	} else {
+		logError("kernel version", err)
+	}
+
+	if cpu, err := sysinfo.GetCPUInfo(); err == nil {
+		info.CPUModel = cpu.Model
+		info.CPUCount = cpu.Count
+	} else {
+		logError("cpu info", err)
+	}
+
+	if memory, err := sysinfo.GetMemoryInfo(); err == nil {
+		info.MemoryTotal = int64(memory.Total)
+		info.MemoryAvailable = int64(memory.Available)
+	} else {
+		logError("memory info", err)
+	}
+
+	if disk, err := sysinfo.GetDiskInfo(); err == nil {
+		info.DiskTotal = int64(disk.Total)
+		info.DiskFree = int64(disk.Free)
+	} else {
+		logError("disk info", err)
+	}
+
+	if uptime, err := sysinfo.Uptime(); err == nil {
+		info.Uptime = int64(uptime.Seconds())
+	} else {
+		logError("uptime", err)
+	}
+
+	if interfaces, err := sysinfo.InterfacesAddresses(); err == nil {
+		info.Interfaces = nil
+		for _, iface := range interfaces {
+			info.Interfaces = append(info.Interfaces, models.DeviceInterface{
+				Name:      iface.Name,
+				Addresses: iface.Addresses,
+			})
+		}
+	} else {
+		logError("network interfaces", err)
+	}
+}
+
+// checkUpdate returns the version the agent must be updated to, if any. The
+// version assigned by the server during the authorization takes precedence
+// over the latest release, even if it is older than the current one.
+func (a *Agent) checkUpdate(current *semver.Version) (*semver.Version, error) {
+	a.mu.Lock()
+	assigned := a.authData.UpdateVersion
+	a.mu.Unlock()
+
+	if assigned != "" {
+		a.setUpdateCheck(assigned)
+
+		next, err := semver.NewVersion(assigned)
+		if err != nil || next.Equal(current) {
+			return nil, err
+		}
+
+		return next, nil
+	}
+
 	info, err := a.cli.GetInfo()
 	if err != nil {
 		return nil, err
 	}
 
-	return semver.NewVersion(info.Version)
+	a.setUpdateCheck(info.Version)
+
+	next, err := semver.NewVersion(info.Version)
+	if err != nil || !next.GreaterThan(current) {
+		return nil, err
+	}
+
+	return next, nil
 }
 
 // probeServerInfo probe server information
@@ -138,24 +305,60 @@ func (a *Agent) probeServerInfo() error {
 	return err
 }
 
+// reload applies the settings of opts that can be changed at runtime.
+func (a *Agent) reload(opts *ConfigOptions) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.opts.KeepAliveInterval = opts.KeepAliveInterval
+	a.opts.PreferredHostname = opts.PreferredHostname
+}
+
+func (a *Agent) preferredHostname() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.opts.PreferredHostname
+}
+
 // authorize send auth request to the server
 func (a *Agent) authorize() error {
+	// Refresh the inventory as usage values change over time
+	if a.Info != nil {
+		loadDeviceInventory(a.Info)
+	}
+
 	authData, err := a.cli.AuthDevice(&models.DeviceAuthRequest{
 		Info:     a.Info,
 		Sessions: a.sessions,
 		DeviceAuth: &models.DeviceAuth{
-			Hostname:  a.opts.PreferredHostname,
+			Hostname:  a.preferredHostname(),
 			Identity:  a.Identity,
 			TenantID:  a.opts.TenantID,
 			PublicKey: string(keygen.EncodePublicKeyToPem(a.pubKey)),
 		},
 	})
+	if err != nil {
+		return err
+	}
 
+	// Swap the data instead of updating it in place, as it is read
+	// concurrently, e.g. by the SSH server, through AuthData
+	a.mu.Lock()
 	a.authData = authData
+	a.mu.Unlock()
 
-	return err
+	return nil
+}
+
+// AuthData returns the data of the last authorization of the device.
+func (a *Agent) AuthData() *models.DeviceAuthResponse {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.authData
 }
 
-func (a *Agent) newReverseListener() (*revdial.Listener, error) {
-	return a.cli.NewReverseListener(a.authData.Token)
+func (a *Agent) newReverseListener() (net.Listener, error) {
+	return a.cli.NewReverseListener(a.AuthData().Token)
 }
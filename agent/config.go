@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// configField is an option of ConfigOptions along with where it is set.
+type configField struct {
+	key   string
+	field reflect.StructField
+	value reflect.Value
+}
+
+func configFields(opts *ConfigOptions) []configField {
+	v := reflect.ValueOf(opts).Elem()
+
+	fields := make([]configField, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		fields = append(fields, configField{
+			key:   field.Tag.Get("envconfig"),
+			field: field,
+			value: v.Field(i),
+		})
+	}
+
+	return fields
+}
+
+// loadConfig loads the agent configuration from the YAML file at path, if
+// any, and from the environment variables, which take precedence over the
+// values in the file. The options set by neither take their defaults.
+func loadConfig(path string) (*ConfigOptions, error) {
+	opts := &ConfigOptions{}
+
+	for _, f := range configFields(opts) {
+		if def, ok := f.field.Tag.Lookup("default"); ok {
+			if err := setField(f.value, def); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := loadConfigFile(path, opts); err != nil {
+		return nil, err
+	}
+
+	if err := loadConfigEnv(opts); err != nil {
+		return nil, err
+	}
+
+	for _, f := range configFields(opts) {
+		if f.field.Tag.Get("required") == "true" && f.value.IsZero() {
+			// show envconfig usage help users to run agent
+			envconfig.Usage("shellhub", opts) // nolint:errcheck
+			return nil, fmt.Errorf("required key %s missing value", envName(f.key))
+		}
+	}
+
+	return opts, nil
+}
+
+// loadConfigFile sets the options in the YAML file at path.
+func loadConfigFile(path string, opts *ConfigOptions) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Empty file
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s:%d: expected a mapping of options", path, root.Line)
+	}
+
+	fields := make(map[string]configField)
+	for _, f := range configFields(opts) {
+		fields[f.key] = f
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		f, ok := fields[key.Value]
+		if !ok {
+			return fmt.Errorf("%s:%d: unknown option %q", path, key.Line, key.Value)
+		}
+
+		// Decode to the type of the option to report invalid values with
+		// their location in the file
+		if value.Kind != yaml.ScalarNode || value.Decode(f.value.Addr().Interface()) != nil {
+			return fmt.Errorf("%s:%d: invalid value for option %q, expected %s", path, value.Line, key.Value, f.field.Type.Kind())
+		}
+	}
+
+	return nil
+}
+
+// loadConfigEnv sets the options in the environment, either prefixed by
+// SHELLHUB_ or unprefixed for backward compatibility.
+func loadConfigEnv(opts *ConfigOptions) error {
+	for _, f := range configFields(opts) {
+		name := envName(f.key)
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			value, ok = os.LookupEnv(strings.ToUpper(f.key))
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := setField(f.value, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func envName(key string) string {
+	return "SHELLHUB_" + strings.ToUpper(key)
+}
+
+// setField parses value to the type of the option.
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported option type %s", field.Kind())
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, dir, content string) string {
+	path := filepath.Join(dir, "agent.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func setenv(t *testing.T, name, value string) {
+	assert.NoError(t, os.Setenv(name, value))
+	t.Cleanup(func() { os.Unsetenv(name) })
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := writeConfigFile(t, dir, `
+server_address: https://cloud.shellhub.io
+private_key: /etc/shellhub.key
+tenant_id: tenant
+keepalive_interval: 60
+enable_jobs: true
+`)
+
+	opts, err := loadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cloud.shellhub.io", opts.ServerAddress)
+	assert.Equal(t, "/etc/shellhub.key", opts.PrivateKey)
+	assert.Equal(t, "tenant", opts.TenantID)
+	assert.Equal(t, 60, opts.KeepAliveInterval)
+	assert.True(t, opts.EnableJobs)
+
+	// Options not in the file take their defaults
+	assert.Equal(t, "/var/run/shellhub-agent.sock", opts.StatusSocket)
+
+	// The values of the file are never exposed to the environment
+	_, ok := os.LookupEnv("SHELLHUB_SERVER_ADDRESS")
+	assert.False(t, ok)
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = loadConfig(writeConfigFile(t, dir, "unknown: value\n"))
+	assert.EqualError(t, err, filepath.Join(dir, "agent.yaml")+`:1: unknown option "unknown"`)
+
+	_, err = loadConfig(writeConfigFile(t, dir, "keepalive_interval: often\n"))
+	assert.EqualError(t, err, filepath.Join(dir, "agent.yaml")+`:1: invalid value for option "keepalive_interval", expected int`)
+
+	_, err = loadConfig(writeConfigFile(t, dir, "tenant_id: tenant\n"))
+	assert.EqualError(t, err, "required key SHELLHUB_SERVER_ADDRESS missing value")
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := writeConfigFile(t, dir, `
+server_address: https://cloud.shellhub.io
+private_key: /etc/shellhub.key
+tenant_id: file
+keepalive_interval: 60
+`)
+
+	// Prefixed variables take precedence over the unprefixed ones, and both
+	// over the file
+	setenv(t, "TENANT_ID", "unprefixed")
+	setenv(t, "SHELLHUB_TENANT_ID", "prefixed")
+	setenv(t, "KEEPALIVE_INTERVAL", "10")
+
+	opts, err := loadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "prefixed", opts.TenantID)
+	assert.Equal(t, 10, opts.KeepAliveInterval)
+	assert.Equal(t, "https://cloud.shellhub.io", opts.ServerAddress)
+
+	setenv(t, "SHELLHUB_KEEPALIVE_INTERVAL", "never")
+
+	_, err = loadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setenv(t, "SHELLHUB_SERVER_ADDRESS", "https://env.shellhub.io")
+
+	path := writeConfigFile(t, dir, `
+server_address: https://cloud.shellhub.io
+private_key: /etc/shellhub.key
+tenant_id: tenant
+keepalive_interval: 60
+`)
+
+	opts, err := loadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://env.shellhub.io", opts.ServerAddress)
+	assert.Equal(t, 60, opts.KeepAliveInterval)
+
+	// Reloading picks up the changes of the file, including removed options
+	// going back to their defaults, while the environment still wins
+	writeConfigFile(t, dir, `
+server_address: https://other.shellhub.io
+private_key: /etc/shellhub.key
+tenant_id: tenant
+`)
+
+	opts, err = loadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://env.shellhub.io", opts.ServerAddress)
+	assert.Equal(t, 30, opts.KeepAliveInterval)
+
+	os.Unsetenv("SHELLHUB_SERVER_ADDRESS")
+
+	opts, err = loadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://other.shellhub.io", opts.ServerAddress)
+}
@@ -12,5 +12,7 @@ var AgentPlatform = "docker"
 func init() {
 	osauth.DefaultShadowFilename = "/host/etc/shadow"
 	sysinfo.DefaultOSReleaseFilename = "/host/etc/os-release"
+	sysinfo.DefaultHostnameFilename = "/host/etc/hostname"
+	sysinfo.DefaultRootFilesystem = "/host"
 
 }
@@ -0,0 +1,200 @@
+package sysinfo
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	DefaultProcFilesystem   = "/proc"
+	DefaultHostnameFilename = "/etc/hostname"
+	DefaultRootFilesystem   = "/"
+)
+
+type CPUInfo struct {
+	Model string
+	Count int
+}
+
+type MemoryInfo struct {
+	Total     uint64
+	Available uint64
+}
+
+type DiskInfo struct {
+	Total uint64
+	Free  uint64
+}
+
+type InterfaceAddresses struct {
+	Name      string
+	Addresses []string
+}
+
+// KernelVersion returns the release of the running kernel.
+func KernelVersion() (string, error) {
+	data, err := ioutil.ReadFile(DefaultProcFilesystem + "/sys/kernel/osrelease")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Hostname returns the hostname of the host, which differs from the one of
+// the process when running inside a container.
+func Hostname() (string, error) {
+	data, err := ioutil.ReadFile(DefaultHostnameFilename)
+	if err == nil && strings.TrimSpace(string(data)) != "" {
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	return os.Hostname()
+}
+
+// GetCPUInfo returns the model and the number of logical processors.
+func GetCPUInfo() (*CPUInfo, error) {
+	file, err := os.Open(DefaultProcFilesystem + "/cpuinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info := &CPUInfo{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		data := strings.SplitN(scanner.Text(), ":", 2)
+		if len(data) != 2 {
+			continue
+		}
+
+		key, value := strings.TrimSpace(data[0]), strings.TrimSpace(data[1])
+		switch key {
+		case "processor":
+			info.Count++
+		case "model name", "Hardware", "Model":
+			// ARM boards report the model on other keys
+			if info.Model == "" {
+				info.Model = value
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if info.Count == 0 {
+		info.Count = runtime.NumCPU()
+	}
+
+	return info, nil
+}
+
+// GetMemoryInfo returns the total and the available memory in bytes.
+func GetMemoryInfo() (*MemoryInfo, error) {
+	file, err := os.Open(DefaultProcFilesystem + "/meminfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info := &MemoryInfo{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// Values are reported in kB
+		switch fields[0] {
+		case "MemTotal:":
+			info.Total = value * 1024
+		case "MemAvailable:":
+			info.Available = value * 1024
+		}
+	}
+
+	return info, scanner.Err()
+}
+
+// GetDiskInfo returns the size and the space available to unprivileged users
+// in bytes of the root filesystem.
+func GetDiskInfo() (*DiskInfo, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(DefaultRootFilesystem, &stat); err != nil {
+		return nil, err
+	}
+
+	return &DiskInfo{
+		Total: stat.Blocks * uint64(stat.Bsize),
+		Free:  stat.Bavail * uint64(stat.Bsize),
+	}, nil
+}
+
+// Uptime returns for how long the host is running.
+func Uptime() (time.Duration, error) {
+	data, err := ioutil.ReadFile(DefaultProcFilesystem + "/uptime")
+	if err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, strconv.ErrSyntax
+	}
+
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// InterfacesAddresses returns the IP addresses of all non loopback
+// interfaces that are up.
+func InterfacesAddresses() ([]InterfaceAddresses, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []InterfaceAddresses
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback > 0 || iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		var addresses []string
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok {
+				addresses = append(addresses, ipnet.IP.String())
+			}
+		}
+
+		if len(addresses) > 0 {
+			list = append(list, InterfaceAddresses{Name: iface.Name, Addresses: addresses})
+		}
+	}
+
+	return list, nil
+}
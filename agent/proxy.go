@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// portDialTimeout bounds the time to connect to a local port.
+const portDialTimeout = 10 * time.Second
+
+// proxyService forwards a request received through the tunnel to the HTTP
+// server listening on port of the loopback interface, stripping the
+// /http/<port> prefix from the path.
+func proxyService(w http.ResponseWriter, r *http.Request, port string) {
+	prefix := "/http/" + port
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = net.JoinHostPort("127.0.0.1", port)
+			req.URL.Path = strings.TrimPrefix(req.URL.Path, prefix)
+			req.URL.RawPath = ""
+
+			// Prevent the default user agent from being set
+			if _, ok := req.Header["User-Agent"]; !ok {
+				req.Header.Set("User-Agent", "")
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			logrus.WithFields(logrus.Fields{
+				"port": port,
+				"err":  err,
+			}).Warn("Failed to proxy request to local service")
+
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	proxy.ServeHTTP(w, r)
+}
+
+// forwardPort connects the tunnel connection of the request to the TCP port of
+// the loopback interface. Once connected, it switches protocols and the
+// connection carries the raw stream.
+func forwardPort(w http.ResponseWriter, r *http.Request, port string) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", port), portDialTimeout)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"port": port,
+			"err":  err,
+		}).Warn("Failed to connect to local port")
+
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	client, err := switchProtocols(w, "tcp")
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	go func() {
+		io.Copy(conn, client) // nolint:errcheck
+		conn.Close()
+	}()
+
+	io.Copy(client, conn) // nolint:errcheck
+	client.Close()
+}
+
+// switchProtocols takes over the tunnel connection of the request, answering
+// that it now carries the given protocol.
+func switchProtocols(w http.ResponseWriter, protocol string) (net.Conn, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return nil, errors.New("hijacking not supported")
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\nConnection: Upgrade\r\nUpgrade: %s\r\n\r\n", // nolint:errcheck
+		http.StatusSwitchingProtocols, http.StatusText(http.StatusSwitchingProtocols), protocol)
+	if err := buf.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &bufferedConn{Conn: conn, reader: buf.Reader}, nil
+}
+
+// bufferedConn reads from the reader of the hijacked connection, which may
+// already hold data sent after the request.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
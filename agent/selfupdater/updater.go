@@ -8,4 +8,6 @@ type Updater interface {
 	CurrentVersion() (*semver.Version, error)
 	ApplyUpdate(v *semver.Version) error
 	CompleteUpdate() error
+	// ConfirmUpdate is called once the agent is connected to the server.
+	ConfirmUpdate() error
 }
@@ -120,6 +120,10 @@ func (d *dockerUpdater) CompleteUpdate() error {
 	return nil
 }
 
+func (d *dockerUpdater) ConfirmUpdate() error {
+	return nil
+}
+
 func (d *dockerUpdater) getContainer(id string) (*dockerContainer, error) {
 	ctx := context.Background()
 
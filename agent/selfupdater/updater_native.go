@@ -3,25 +3,390 @@
 package selfupdater
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
 	"github.com/Masterminds/semver"
+	"github.com/sirupsen/logrus"
+)
+
+// PublicKey is the base64 encoded Ed25519 key used to verify the signature
+// of the release artifacts. It is injected using `-ldflags` build option
+// (e.g: `go build -ldflags "-X github.com/shellhub-io/shellhub/agent/selfupdater.PublicKey=..."`).
+var PublicKey string
+
+// ReleaseURL is the address where the release artifacts are downloaded from.
+// The artifacts of each version are expected at
+// <ReleaseURL>/<version>/shellhub-agent-linux-<arch> along with its manifest
+// (.manifest) and the base64 encoded Ed25519 signature of the manifest
+// (.manifest.sig).
+var ReleaseURL = "https://github.com/shellhub-io/shellhub/releases/download"
+
+// RollbackTimeout is how long the new version has to establish a tunnel
+// before the previous version is restored.
+var RollbackTimeout = 5 * time.Minute
+
+var (
+	ErrMissingPublicKey  = errors.New("no public key to verify updates")
+	ErrChecksumMismatch  = errors.New("checksum mismatch")
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrDownloadFailed    = errors.New("failed to download release artifact")
+	ErrInvalidUpdateFile = errors.New("invalid update state file")
+	ErrInvalidManifest   = errors.New("invalid release manifest")
+	ErrManifestMismatch  = errors.New("release manifest does not match the update")
 )
 
+// manifest describes a release artifact. It is signed as a whole so that a
+// valid signature of an artifact cannot be replayed for other version or
+// architecture.
+type manifest struct {
+	Version string `json:"version"`
+	Arch    string `json:"arch"`
+	SHA256  string `json:"sha256"`
+}
+
+// verifyManifest checks the signature of the manifest in data and that it
+// describes the artifact of version v for arch.
+func verifyManifest(key ed25519.PublicKey, data, signature []byte, v *semver.Version, arch string) (*manifest, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(key, data, sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	m := &manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, ErrInvalidManifest
+	}
+
+	if !sameVersion(m.Version, v.Original()) || m.Arch != arch {
+		return nil, ErrManifestMismatch
+	}
+
+	return m, nil
+}
+
+// updateState is persisted while an update is not confirmed, so that a
+// version that does not start at all is rolled back on the next start.
+type updateState struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Attempts int    `json:"attempts"`
+}
+
 type nativeUpdater struct {
-	version string
+	version    string
+	executable string
+
+	mu    sync.Mutex
+	timer *time.Timer
 }
 
 func (n *nativeUpdater) CurrentVersion() (*semver.Version, error) {
 	return semver.NewVersion(n.version)
 }
 
-func (n *nativeUpdater) ApplyUpdate(_ *semver.Version) error {
-	return nil
+// ApplyUpdate downloads and verifies the release artifact of v, replaces the
+// running binary by it and re-executes the agent. It only returns on error.
+func (n *nativeUpdater) ApplyUpdate(v *semver.Version) error {
+	key, err := base64.StdEncoding.DecodeString(PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return ErrMissingPublicKey
+	}
+
+	artifact := fmt.Sprintf("%s/%s/shellhub-agent-linux-%s", ReleaseURL, v.Original(), runtime.GOARCH)
+
+	data, err := download(artifact + ".manifest")
+	if err != nil {
+		return err
+	}
+
+	signature, err := download(artifact + ".manifest.sig")
+	if err != nil {
+		return err
+	}
+
+	m, err := verifyManifest(ed25519.PublicKey(key), data, signature, v, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+
+	expected, err := hex.DecodeString(m.SHA256)
+	if err != nil || len(expected) != sha256.Size {
+		return ErrInvalidManifest
+	}
+
+	info, err := os.Stat(n.executable)
+	if err != nil {
+		return err
+	}
+
+	// The new binary is written next to the current one so that it can be
+	// swapped by a rename
+	tmp := n.executable + ".new"
+	if err := downloadFile(artifact, tmp, info.Mode(), expected); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	backup := n.executable + ".old"
+	os.Remove(backup)
+	if err := os.Link(n.executable, backup); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := n.saveState(&updateState{From: n.version, To: v.Original()}); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, n.executable); err != nil {
+		os.Remove(tmp)
+		os.Remove(n.stateFile())
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"from": n.version,
+		"to":   v.Original(),
+	}).Info("Agent updated, restarting")
+
+	return n.exec()
 }
 
+// CompleteUpdate is called on start. If the agent is running a version not
+// confirmed yet, it rolls back when the version already failed to start or
+// schedules the rollback in case the tunnel is not established in time.
 func (n *nativeUpdater) CompleteUpdate() error {
+	state, err := n.loadState()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		logrus.WithFields(logrus.Fields{"err": err}).Warn("Discarding update state")
+		n.cleanup()
+
+		return nil
+	}
+
+	// Running the previous version, the update was already rolled back
+	if !sameVersion(state.To, n.version) {
+		n.cleanup()
+		return nil
+	}
+
+	if state.Attempts > 0 {
+		logrus.WithFields(logrus.Fields{
+			"version": state.To,
+		}).Warn("Updated agent failed to start, rolling back")
+
+		if err := n.rollback(); err != nil {
+			logrus.WithFields(logrus.Fields{"err": err}).Error("Failed to roll back update, keeping the current version")
+		}
+
+		return nil
+	}
+
+	state.Attempts++
+	if err := n.saveState(state); err != nil {
+		logrus.WithFields(logrus.Fields{"err": err}).Warn("Failed to save update state")
+	}
+
+	n.mu.Lock()
+	n.timer = time.AfterFunc(RollbackTimeout, func() {
+		logrus.WithFields(logrus.Fields{
+			"version": state.To,
+			"timeout": RollbackTimeout,
+		}).Warn("Updated agent failed to connect to server, rolling back")
+
+		if err := n.rollback(); err != nil {
+			logrus.WithFields(logrus.Fields{"err": err}).Error("Failed to roll back update")
+		}
+	})
+	n.mu.Unlock()
+
+	return nil
+}
+
+// ConfirmUpdate marks the running version as good once the tunnel is
+// established, cancelling the scheduled rollback.
+func (n *nativeUpdater) ConfirmUpdate() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.timer == nil {
+		return nil
+	}
+
+	if !n.timer.Stop() {
+		// The rollback is already in progress
+		return nil
+	}
+
+	n.timer = nil
+	n.cleanup()
+
+	return nil
+}
+
+// rollback restores the previous binary and re-executes the agent. The update
+// state is cleared even if the previous binary cannot be restored, so the
+// agent keeps running the current version instead of retrying on every start.
+func (n *nativeUpdater) rollback() error {
+	err := os.Rename(n.executable+".old", n.executable)
+
+	os.Remove(n.stateFile())
+
+	if err != nil {
+		return err
+	}
+
+	return n.exec()
+}
+
+func (n *nativeUpdater) cleanup() {
+	os.Remove(n.stateFile())
+	os.Remove(n.executable + ".old")
+}
+
+func (n *nativeUpdater) exec() error {
+	return syscall.Exec(n.executable, os.Args, os.Environ())
+}
+
+func (n *nativeUpdater) stateFile() string {
+	return n.executable + ".update"
+}
+
+func (n *nativeUpdater) loadState() (*updateState, error) {
+	data, err := ioutil.ReadFile(n.stateFile())
+	if err != nil {
+		return nil, err
+	}
+
+	state := &updateState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, ErrInvalidUpdateFile
+	}
+
+	return state, nil
+}
+
+func (n *nativeUpdater) saveState(state *updateState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp := n.stateFile() + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, n.stateFile())
+}
+
+func sameVersion(a, b string) bool {
+	va, err := semver.NewVersion(a)
+	if err != nil {
+		return a == b
+	}
+
+	vb, err := semver.NewVersion(b)
+	if err != nil {
+		return a == b
+	}
+
+	return va.Equal(vb)
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Minute}
+
+func get(url string) (*http.Response, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s: %s", ErrDownloadFailed, url, resp.Status)
+	}
+
+	return resp, nil
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+}
+
+// downloadFile downloads url to path, checking its SHA-256 checksum.
+func downloadFile(url, path string, mode os.FileMode, checksum []byte) error {
+	resp, err := get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), resp.Body); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(hash.Sum(nil), checksum) {
+		return ErrChecksumMismatch
+	}
+
 	return nil
 }
 
 func NewUpdater(version string) (Updater, error) {
-	return &nativeUpdater{version}, nil
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nativeUpdater{version: version, executable: executable}, nil
 }
@@ -0,0 +1,170 @@
+// +build !docker
+
+package selfupdater
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func signManifest(t *testing.T, key ed25519.PrivateKey, m manifest) ([]byte, []byte) {
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+
+	return data, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)))
+}
+
+func TestVerifyManifest(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	v := semver.MustParse("v0.6.0")
+	digest := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	cases := []struct {
+		name     string
+		key      ed25519.PrivateKey
+		manifest manifest
+		err      error
+	}{
+		{"valid", priv, manifest{Version: "v0.6.0", Arch: "amd64", SHA256: digest}, nil},
+		{"other key", other, manifest{Version: "v0.6.0", Arch: "amd64", SHA256: digest}, ErrInvalidSignature},
+		{"other version", priv, manifest{Version: "v0.5.0", Arch: "amd64", SHA256: digest}, ErrManifestMismatch},
+		{"other arch", priv, manifest{Version: "v0.6.0", Arch: "arm64", SHA256: digest}, ErrManifestMismatch},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, sig := signManifest(t, tc.key, tc.manifest)
+
+			m, err := verifyManifest(pub, data, sig, v, "amd64")
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, digest, m.SHA256)
+			}
+		})
+	}
+
+	data, sig := signManifest(t, priv, manifest{Version: "v0.6.0", Arch: "amd64", SHA256: digest})
+
+	_, err = verifyManifest(pub, append(data, ' '), sig, v, "amd64")
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	_, err = verifyManifest(pub, data, []byte("invalid"), v, "amd64")
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func newTestUpdater(t *testing.T, version string) (*nativeUpdater, string) {
+	dir, err := ioutil.TempDir("", "selfupdater")
+	assert.NoError(t, err)
+
+	executable := filepath.Join(dir, "agent")
+	assert.NoError(t, ioutil.WriteFile(executable, []byte("current"), 0700))
+
+	return &nativeUpdater{version: version, executable: executable}, dir
+}
+
+func TestApplyUpdateManifestMismatch(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	// A validly signed manifest of other version must not be accepted
+	data, sig := signManifest(t, priv, manifest{
+		Version: "v0.5.0",
+		Arch:    runtime.GOARCH,
+		SHA256:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch filepath.Ext(req.URL.Path) {
+		case ".manifest":
+			res.Write(data) // nolint:errcheck
+		case ".sig":
+			res.Write(sig) // nolint:errcheck
+		default:
+			res.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer func(url, key string) { ReleaseURL, PublicKey = url, key }(ReleaseURL, PublicKey)
+	ReleaseURL = server.URL
+	PublicKey = base64.StdEncoding.EncodeToString(pub)
+
+	n, dir := newTestUpdater(t, "v0.5.0")
+	defer os.RemoveAll(dir)
+
+	err = n.ApplyUpdate(semver.MustParse("v0.6.0"))
+	assert.Equal(t, ErrManifestMismatch, err)
+
+	data, err = ioutil.ReadFile(n.executable)
+	assert.NoError(t, err)
+	assert.Equal(t, "current", string(data))
+}
+
+func TestCompleteUpdateFailedRollback(t *testing.T) {
+	n, dir := newTestUpdater(t, "v0.6.0")
+	defer os.RemoveAll(dir)
+
+	// The new version already failed to start once but the previous binary
+	// is gone, so it must keep running the current one
+	assert.NoError(t, n.saveState(&updateState{From: "v0.5.0", To: "v0.6.0", Attempts: 1}))
+
+	assert.NoError(t, n.CompleteUpdate())
+
+	_, err := os.Stat(n.stateFile())
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, n.CompleteUpdate())
+}
+
+func TestCompleteUpdateRolledBack(t *testing.T) {
+	n, dir := newTestUpdater(t, "v0.5.0")
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, n.saveState(&updateState{From: "v0.5.0", To: "v0.6.0", Attempts: 1}))
+	assert.NoError(t, ioutil.WriteFile(n.executable+".old", []byte("previous"), 0700))
+
+	assert.NoError(t, n.CompleteUpdate())
+
+	for _, path := range []string{n.stateFile(), n.executable + ".old"} {
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestCompleteUpdateConfirm(t *testing.T) {
+	n, dir := newTestUpdater(t, "v0.6.0")
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, n.saveState(&updateState{From: "v0.5.0", To: "v0.6.0"}))
+	assert.NoError(t, ioutil.WriteFile(n.executable+".old", []byte("previous"), 0700))
+
+	assert.NoError(t, n.CompleteUpdate())
+
+	state, err := n.loadState()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, state.Attempts)
+
+	assert.NoError(t, n.ConfirmUpdate())
+
+	for _, path := range []string{n.stateFile(), n.executable + ".old"} {
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
@@ -39,7 +39,7 @@ func (c *sshConn) Close() error {
 type Server struct {
 	sshd              *sshserver.Server
 	api               client.Client
-	authData          *models.DeviceAuthResponse
+	authData          func() *models.DeviceAuthResponse
 	cmds              map[string]*exec.Cmd
 	Sessions          map[string]net.Conn
 	deviceName        string
@@ -47,7 +47,9 @@ type Server struct {
 	keepAliveInterval int
 }
 
-func NewServer(api client.Client, authData *models.DeviceAuthResponse, privateKey string, keepAliveInterval int) *Server {
+// NewServer returns the SSH server of the device, which reads the current
+// authorization of the device through authData.
+func NewServer(api client.Client, authData func() *models.DeviceAuthResponse, privateKey string, keepAliveInterval int) *Server {
 	s := &Server{
 		api:               api,
 		authData:          authData,
@@ -93,6 +95,13 @@ func (s *Server) HandleConn(conn net.Conn) {
 	s.sshd.HandleConn(conn)
 }
 
+func (s *Server) SetKeepAliveInterval(interval int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keepAliveInterval = interval
+}
+
 func (s *Server) SetDeviceName(name string) {
 	s.deviceName = name
 }
@@ -107,7 +116,11 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 
 	log.Info("New session request")
 
-	go StartKeepAliveLoop(time.Second*time.Duration(s.keepAliveInterval), session)
+	s.mu.Lock()
+	keepAliveInterval := s.keepAliveInterval
+	s.mu.Unlock()
+
+	go StartKeepAliveLoop(time.Second*time.Duration(keepAliveInterval), session)
 
 	if isPty {
 		scmd := newShellCmd(s, session.User(), sspty.Term)
@@ -161,6 +174,7 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 
 		stdout, _ := cmd.StdoutPipe()
 		stdin, _ := cmd.StdinPipe()
+		cmd.Stderr = session.Stderr()
 
 		logrus.WithFields(logrus.Fields{
 			"user":        session.User(),
@@ -180,11 +194,10 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 			}
 		}()
 
-		go func() {
-			if _, err := io.Copy(session, stdout); err != nil {
-				fmt.Println(err)
-			}
-		}()
+		// The output must be fully read before waiting for the command
+		if _, err := io.Copy(session, stdout); err != nil {
+			fmt.Println(err)
+		}
 
 		err = cmd.Wait()
 		if err != nil {
@@ -197,7 +210,25 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 			"localaddr":   session.LocalAddr(),
 			"Raw command": session.RawCommand(),
 		}).Info("Command ended")
+
+		session.Exit(exitCode(cmd, err)) // nolint:errcheck
+	}
+}
+
+// exitCode returns the exit status of a command, 255 when it couldn't be
+// run at all.
+func exitCode(cmd *exec.Cmd, err error) int {
+	if cmd.ProcessState != nil {
+		if code := cmd.ProcessState.ExitCode(); code >= 0 {
+			return code
+		}
 	}
+
+	if err != nil {
+		return 255
+	}
+
+	return 0
 }
 
 func (s *Server) passwordHandler(ctx sshserver.Context, pass string) bool {
@@ -241,7 +272,7 @@ func (s *Server) publicKeyHandler(ctx sshserver.Context, key sshserver.PublicKey
 	res, err := s.api.AuthPublicKey(&models.PublicKeyAuthRequest{
 		Fingerprint: ssh.FingerprintLegacyMD5(key),
 		Data:        string(sigBytes),
-	}, s.authData.Token)
+	}, s.authData().Token)
 	if err != nil {
 		return false
 	}
@@ -262,10 +293,35 @@ func (s *Server) publicKeyHandler(ctx sshserver.Context, key sshserver.PublicKey
 	return true
 }
 
+// AddSession registers the connection of an active session.
+func (s *Server) AddSession(id string, conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Sessions[id] = conn
+}
+
+// ListSessions returns the ids of the active sessions.
+func (s *Server) ListSessions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := make([]string, 0, len(s.Sessions))
+	for id := range s.Sessions {
+		sessions = append(sessions, id)
+	}
+
+	return sessions
+}
+
 func (s *Server) CloseSession(id string) {
-	if session, ok := s.Sessions[id]; ok {
+	s.mu.Lock()
+	session, ok := s.Sessions[id]
+	delete(s.Sessions, id)
+	s.mu.Unlock()
+
+	if ok {
 		session.Close()
-		delete(s.Sessions, id)
 	}
 }
 
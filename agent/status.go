@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const DefaultStatusSocket = "/var/run/shellhub-agent.sock"
+
+// AgentStatus is the state of the running agent reported on the status socket.
+type AgentStatus struct {
+	Version         string    `json:"version"`
+	ServerAddress   string    `json:"server_address"`
+	UID             string    `json:"uid"`
+	Name            string    `json:"name"`
+	Namespace       string    `json:"namespace"`
+	Connected       bool      `json:"connected"`
+	ConnectedSince  time.Time `json:"connected_since,omitempty"`
+	Sessions        []string  `json:"sessions"`
+	LastUpdateCheck time.Time `json:"last_update_check,omitempty"`
+	LatestVersion   string    `json:"latest_version,omitempty"`
+}
+
+// serveStatus serves the status of the agent on a Unix socket at path, only
+// accessible by root.
+func serveStatus(path string, status func() *AgentStatus) error {
+	// Remove the socket left behind by a previous run
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status()) // nolint:errcheck
+	})
+
+	return http.Serve(listener, mux)
+}
+
+// runStatusCommand implements the status subcommand, printing the status of
+// the agent running on this host.
+func runStatusCommand(args []string, out io.Writer) error {
+	socket := os.Getenv("SHELLHUB_STATUS_SOCKET")
+	if socket == "" {
+		socket = DefaultStatusSocket
+	}
+
+	flags := flag.NewFlagSet("status", flag.ExitOnError)
+	flags.StringVar(&socket, "socket", socket, "path to the agent status socket")
+	asJSON := flags.Bool("json", false, "print the status as JSON")
+	flags.Parse(args) // nolint:errcheck
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://agent/status")
+	if err != nil {
+		return fmt.Errorf("failed to reach the agent at %s: %w", socket, err)
+	}
+	defer resp.Body.Close()
+
+	var status AgentStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+
+	connected := "disconnected"
+	if status.Connected {
+		connected = fmt.Sprintf("connected since %s", status.ConnectedSince.Format(time.RFC3339))
+	}
+
+	lastUpdateCheck := "never"
+	if !status.LastUpdateCheck.IsZero() {
+		lastUpdateCheck = fmt.Sprintf("%s (latest version %s)", status.LastUpdateCheck.Format(time.RFC3339), status.LatestVersion)
+	}
+
+	fmt.Fprintf(out, "Version:           %s\n", status.Version)
+	fmt.Fprintf(out, "Server address:    %s\n", status.ServerAddress)
+	fmt.Fprintf(out, "Device UID:        %s\n", status.UID)
+	fmt.Fprintf(out, "Device name:       %s\n", status.Name)
+	fmt.Fprintf(out, "Namespace:         %s\n", status.Namespace)
+	fmt.Fprintf(out, "Tunnel:            %s\n", connected)
+	fmt.Fprintf(out, "Active sessions:   %d\n", len(status.Sessions))
+	for _, session := range status.Sessions {
+		fmt.Fprintf(out, "  %s\n", session)
+	}
+	fmt.Fprintf(out, "Last update check: %s\n", lastUpdateCheck)
+
+	return nil
+}
+
+// status returns the current status of the agent.
+func (a *Agent) status(sessions []string) *AgentStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	status := &AgentStatus{
+		Version:         AgentVersion,
+		ServerAddress:   a.opts.ServerAddress,
+		Connected:       !a.connectedSince.IsZero(),
+		ConnectedSince:  a.connectedSince,
+		Sessions:        sessions,
+		LastUpdateCheck: a.lastUpdateCheck,
+		LatestVersion:   a.latestVersion,
+	}
+
+	if a.authData != nil {
+		status.UID = a.authData.UID
+		status.Name = a.authData.Name
+		status.Namespace = a.authData.Namespace
+	}
+
+	return status
+}
+
+func (a *Agent) setConnected(connected bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if connected {
+		a.connectedSince = time.Now()
+	} else {
+		a.connectedSince = time.Time{}
+	}
+}
+
+func (a *Agent) setUpdateCheck(version string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lastUpdateCheck = time.Now()
+	a.latestVersion = strings.TrimPrefix(version, "v")
+}
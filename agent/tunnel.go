@@ -6,7 +6,6 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
-	"github.com/shellhub-io/shellhub/pkg/revdial"
 )
 
 type Tunnel struct {
@@ -14,6 +13,9 @@ type Tunnel struct {
 	srv          *http.Server
 	connHandler  func(w http.ResponseWriter, r *http.Request)
 	closeHandler func(w http.ResponseWriter, r *http.Request)
+	jobHandler   func(w http.ResponseWriter, r *http.Request)
+	httpHandler  func(w http.ResponseWriter, r *http.Request)
+	tcpHandler   func(w http.ResponseWriter, r *http.Request)
 }
 
 func NewTunnel() *Tunnel {
@@ -33,6 +35,15 @@ func NewTunnel() *Tunnel {
 		closeHandler: func(w http.ResponseWriter, r *http.Request) {
 			panic("closeHandler can not be nil")
 		},
+		jobHandler: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not implemented", http.StatusNotImplemented)
+		},
+		httpHandler: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not implemented", http.StatusNotImplemented)
+		},
+		tcpHandler: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not implemented", http.StatusNotImplemented)
+		},
 	}
 	t.router.HandleFunc("/ssh/{id}", func(w http.ResponseWriter, r *http.Request) {
 		t.connHandler(w, r)
@@ -40,11 +51,20 @@ func NewTunnel() *Tunnel {
 	t.router.HandleFunc("/ssh/close/{id}", func(w http.ResponseWriter, r *http.Request) {
 		t.closeHandler(w, r)
 	})
+	t.router.HandleFunc("/ssh/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		t.jobHandler(w, r)
+	})
+	t.router.PathPrefix("/http/{port:[0-9]+}/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.httpHandler(w, r)
+	})
+	t.router.HandleFunc("/tcp/{port:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		t.tcpHandler(w, r)
+	})
 
 	return t
 }
 
 // Listen to reverse listener
-func (t *Tunnel) Listen(l *revdial.Listener) error {
+func (t *Tunnel) Listen(l net.Listener) error {
 	return t.srv.Serve(l)
 }
@@ -0,0 +1,71 @@
+package auditlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	sink, err := NewFileSink(path, 1024, 2)
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	for i := 0; i < 3; i++ {
+		err = sink.Write(&models.AuditEvent{Action: ActionUserLogin, Target: "id"})
+		assert.NoError(t, err)
+	}
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event models.AuditEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		assert.Equal(t, ActionUserLogin, event.Action)
+		lines++
+	}
+
+	assert.Equal(t, 3, lines)
+}
+
+func TestFileSinkRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	// Small enough to rotate on every write
+	sink, err := NewFileSink(path, 10, 2)
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	for i := 0; i < 4; i++ {
+		err = sink.Write(&models.AuditEvent{Action: ActionUserLogin, Target: "id"})
+		assert.NoError(t, err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		_, err := os.Stat(name)
+		assert.NoError(t, err)
+	}
+
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
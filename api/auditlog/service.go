@@ -0,0 +1,111 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/pkg/api/paginator"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ActionDeviceDelete          = "device.delete"
+	ActionDeviceRename          = "device.rename"
+	ActionDeviceStatus          = "device.status"
+	ActionDeviceUpdatePolicy    = "device.update.policy"
+	ActionNamespaceCreate       = "namespace.create"
+	ActionNamespaceDelete       = "namespace.delete"
+	ActionNamespaceRename       = "namespace.rename"
+	ActionNamespaceMemberAdd    = "namespace.member.add"
+	ActionNamespaceMemberRemove = "namespace.member.remove"
+	ActionNamespaceSettings     = "namespace.settings"
+	ActionPublicKeyCreate       = "publickey.create"
+	ActionPublicKeyUpdate       = "publickey.update"
+	ActionPublicKeyDelete       = "publickey.delete"
+	ActionUserUpdate            = "user.update"
+	ActionUserLogin             = "user.login"
+	ActionUserLoginFailed       = "user.login.failed"
+	ActionDeviceAuth            = "device.auth"
+	ActionSessionCreate         = "session.create"
+	ActionSessionFinish         = "session.finish"
+	ActionSessionTerminate      = "session.terminate"
+	ActionSessionShadow         = "session.shadow"
+	ActionJobCreate             = "job.create"
+)
+
+type Service interface {
+	ListEvents(ctx context.Context, pagination paginator.Query, filterB64 string) ([]models.AuditEvent, int, error)
+}
+
+type service struct {
+	store store.Store
+}
+
+func NewService(store store.Store) Service {
+	return &service{store}
+}
+
+func (s *service) ListEvents(ctx context.Context, pagination paginator.Query, filterB64 string) ([]models.AuditEvent, int, error) {
+	raw, err := base64.StdEncoding.DecodeString(filterB64)
+	if err != nil {
+		return nil, 0, store.ErrInvalidFilter
+	}
+
+	var filter []models.Filter
+
+	if err := json.Unmarshal([]byte(raw), &filter); len(raw) > 0 && err != nil {
+		return nil, 0, store.ErrInvalidFilter
+	}
+
+	return s.store.ListAuditEvents(ctx, pagination, filter)
+}
+
+// Record stores an audit event and emits it to the registered sinks. The
+// actor and tenant are taken from the request context when not set in the
+// event.
+//
+// Recording is best-effort: the action being audited has already taken place
+// when this is called, so a failure to store the event is only logged and not
+// reported as a failure of the action itself.
+func Record(ctx context.Context, store store.Store, event models.AuditEvent) {
+	fillEvent(ctx, &event)
+
+	if err := store.CreateAuditEvent(ctx, &event); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"action": event.Action,
+			"tenant": event.TenantID,
+			"err":    err,
+		}).Error("Failed to store audit event")
+	}
+
+	emitToSinks(&event)
+}
+
+// Emit sends an event only to the registered sinks. It is meant for events
+// already kept elsewhere in the database, like sessions.
+func Emit(ctx context.Context, event models.AuditEvent) {
+	fillEvent(ctx, &event)
+
+	emitToSinks(&event)
+}
+
+func fillEvent(ctx context.Context, event *models.AuditEvent) {
+	event.Time = time.Now()
+
+	if event.Actor == "" {
+		if username := apicontext.UsernameFromContext(ctx); username != nil {
+			event.Actor = username.ID
+		}
+	}
+
+	if event.TenantID == "" {
+		if tenant := apicontext.TenantFromContext(ctx); tenant != nil {
+			event.TenantID = tenant.ID
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/api/store/mocks"
+	"github.com/shellhub-io/shellhub/pkg/api/paginator"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+)
+
+func TestListEvents(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	events := []models.AuditEvent{
+		{Action: ActionDeviceRename, Target: "uid"},
+	}
+
+	filters := []models.Filter{
+		{
+			Type:   "property",
+			Params: &models.PropertyParams{Name: "action", Operator: "eq", Value: ActionDeviceRename}},
+	}
+
+	filterJSON, err := json.Marshal(filters)
+	assert.NoError(t, err)
+
+	encodedFilter := base64.StdEncoding.EncodeToString(filterJSON)
+
+	query := paginator.Query{Page: 1, PerPage: 10}
+
+	mock.On("ListAuditEvents", ctx, query, filters).
+		Return(events, len(events), nil).Once()
+
+	returnedEvents, count, err := s.ListEvents(ctx, query, encodedFilter)
+	assert.NoError(t, err)
+	assert.Equal(t, events, returnedEvents)
+	assert.Equal(t, count, len(events))
+
+	mock.AssertExpectations(t)
+}
+
+func TestRecord(t *testing.T) {
+	mock := &mocks.Store{}
+
+	ctx := context.TODO()
+
+	event := models.AuditEvent{
+		TenantID: "tenant",
+		Actor:    "username",
+		Action:   ActionDeviceRename,
+		Target:   "uid",
+		Before:   "old",
+		After:    "new",
+	}
+
+	mock.On("CreateAuditEvent", ctx, testifymock.MatchedBy(func(e *models.AuditEvent) bool {
+		return !e.Time.IsZero() && e.TenantID == event.TenantID && e.Actor == event.Actor &&
+			e.Action == event.Action && e.Target == event.Target && e.Before == event.Before && e.After == event.After
+	})).Return(nil).Once()
+
+	Record(ctx, store.Store(mock), event)
+
+	mock.AssertExpectations(t)
+}
+
+func TestRecordFailure(t *testing.T) {
+	mock := &mocks.Store{}
+
+	ctx := context.TODO()
+
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).
+		Return(errors.New("connection lost")).Once()
+
+	Record(ctx, store.Store(mock), models.AuditEvent{TenantID: "tenant", Action: ActionDeviceRename})
+
+	entry := hook.LastEntry()
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, logrus.ErrorLevel, entry.Level)
+		assert.Equal(t, ActionDeviceRename, entry.Data["action"])
+		assert.Equal(t, "tenant", entry.Data["tenant"])
+	}
+
+	mock.AssertExpectations(t)
+}
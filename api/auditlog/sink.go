@@ -0,0 +1,88 @@
+package auditlog
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// sinkBufferSize is the number of events queued for each sink. Events
+// emitted while the queue is full are dropped.
+const sinkBufferSize = 1024
+
+// Sink receives a copy of every event emitted by the audit log so it can be
+// streamed to an external system.
+type Sink interface {
+	Write(event *models.AuditEvent) error
+}
+
+var (
+	sinksMu sync.RWMutex
+	sinks   []*queuedSink
+
+	droppedEvents = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "shellhub",
+		Subsystem: "api",
+		Name:      "audit_events_dropped_total",
+		Help:      "Audit events not delivered to a sink because its queue was full.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(droppedEvents)
+}
+
+// queuedSink feeds a sink from a background worker, so slow or unreachable
+// sinks never hold the requests emitting the events.
+type queuedSink struct {
+	sink   Sink
+	events chan *models.AuditEvent
+}
+
+// RegisterSink adds a sink to the list of sinks fed by Record and Emit.
+func RegisterSink(sink Sink) {
+	s := &queuedSink{
+		sink:   sink,
+		events: make(chan *models.AuditEvent, sinkBufferSize),
+	}
+
+	go s.run()
+
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+
+	sinks = append(sinks, s)
+}
+
+func (s *queuedSink) run() {
+	for event := range s.events {
+		// Sinks are best-effort, just like storing the event
+		if err := s.sink.Write(event); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"action": event.Action,
+				"tenant": event.TenantID,
+				"err":    err,
+			}).Error("Failed to write audit event to sink")
+		}
+	}
+}
+
+func emitToSinks(event *models.AuditEvent) {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+
+	for _, s := range sinks {
+		select {
+		case s.events <- event:
+		default:
+			droppedEvents.Inc()
+
+			logrus.WithFields(logrus.Fields{
+				"action": event.Action,
+				"tenant": event.TenantID,
+			}).Warn("Audit sink queue is full, dropping event")
+		}
+	}
+}
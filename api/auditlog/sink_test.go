@@ -0,0 +1,62 @@
+package auditlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingSink struct {
+	release chan struct{}
+	written chan *models.AuditEvent
+}
+
+func (s *blockingSink) Write(event *models.AuditEvent) error {
+	<-s.release
+	s.written <- event
+
+	return nil
+}
+
+func TestEmitToSinksDoesNotBlock(t *testing.T) {
+	defer func() { sinks = nil }()
+
+	sink := &blockingSink{
+		release: make(chan struct{}),
+		written: make(chan *models.AuditEvent, sinkBufferSize+1),
+	}
+
+	RegisterSink(sink)
+
+	dropped := testutil.ToFloat64(droppedEvents)
+
+	done := make(chan struct{})
+	go func() {
+		// One event is held by the worker, the others fill the queue
+		for i := 0; i < sinkBufferSize+10; i++ {
+			emitToSinks(&models.AuditEvent{Action: ActionUserLogin})
+		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("emitting events blocked on a slow sink")
+	}
+
+	assert.True(t, testutil.ToFloat64(droppedEvents)-dropped >= 9)
+
+	close(sink.release)
+
+	select {
+	case event := <-sink.written:
+		assert.Equal(t, ActionUserLogin, event.Action)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not written to the sink")
+	}
+}
@@ -0,0 +1,151 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shellhub-io/shellhub/pkg/models"
+)
+
+var ErrInvalidSyslogAddress = errors.New("invalid syslog address")
+
+const (
+	// Facility used for every message ("log audit" in RFC 5424)
+	syslogFacility = 13
+
+	syslogSeverityWarning = 4
+	syslogSeverityInfo    = 6
+
+	// Private enterprise number used to scope the structured data element
+	syslogSDID = "shellhub@32473"
+
+	syslogTimeout = 5 * time.Second
+)
+
+// SyslogSink writes events as RFC 5424 messages to a remote syslog server
+// over TCP or UDP. TCP messages are framed using octet counting (RFC 6587).
+type SyslogSink struct {
+	network  string
+	address  string
+	hostname string
+	appName  string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink returns a sink for addr, which must be in the form
+// tcp://host:port or udp://host:port.
+func NewSyslogSink(addr string) (*SyslogSink, error) {
+	u, err := url.Parse(addr)
+	if err != nil || u.Host == "" || (u.Scheme != "tcp" && u.Scheme != "udp") {
+		return nil, ErrInvalidSyslogAddress
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	return &SyslogSink{
+		network:  u.Scheme,
+		address:  u.Host,
+		hostname: hostname,
+		appName:  "shellhub-api",
+	}, nil
+}
+
+func (s *SyslogSink) Write(event *models.AuditEvent) error {
+	msg, err := s.format(event)
+	if err != nil {
+		return err
+	}
+
+	if s.network == "tcp" {
+		msg = []byte(fmt.Sprintf("%d %s", len(msg), msg))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Retry once with a fresh connection since the server may have closed
+	// the previous one
+	for i := 0; i < 2; i++ {
+		if s.conn == nil {
+			s.conn, err = net.DialTimeout(s.network, s.address, syslogTimeout)
+			if err != nil {
+				return err
+			}
+		}
+
+		s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout)) // nolint:errcheck
+
+		if _, err = s.conn.Write(msg); err == nil {
+			return nil
+		}
+
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	return err
+}
+
+func (s *SyslogSink) format(event *models.AuditEvent) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	severity := syslogSeverityInfo
+	if strings.HasSuffix(event.Action, ".failed") {
+		severity = syslogSeverityWarning
+	}
+
+	sd := fmt.Sprintf("[%s tenant=\"%s\" actor=\"%s\" target=\"%s\"]",
+		syslogSDID, escapeSDParam(event.TenantID), escapeSDParam(event.Actor), escapeSDParam(event.Target))
+
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		syslogFacility*8+severity,
+		event.Time.UTC().Format(time.RFC3339Nano),
+		syslogField(s.hostname, 255),
+		syslogField(s.appName, 48),
+		os.Getpid(),
+		syslogField(event.Action, 32),
+		sd,
+		data,
+	)), nil
+}
+
+// syslogField returns value as a valid header field: printable US-ASCII
+// without spaces, limited to max characters, or the nil value "-".
+func syslogField(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+
+		return r
+	}, value)
+
+	if value == "" {
+		return "-"
+	}
+
+	if len(value) > max {
+		value = value[:max]
+	}
+
+	return value
+}
+
+func escapeSDParam(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
@@ -0,0 +1,64 @@
+package auditlog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSyslogSink(t *testing.T) {
+	_, err := NewSyslogSink("localhost:514")
+	assert.Equal(t, ErrInvalidSyslogAddress, err)
+
+	_, err = NewSyslogSink("http://localhost:514")
+	assert.Equal(t, ErrInvalidSyslogAddress, err)
+
+	_, err = NewSyslogSink("udp://localhost:514")
+	assert.NoError(t, err)
+}
+
+func TestSyslogSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	sink, err := NewSyslogSink("tcp://" + ln.Addr().String())
+	assert.NoError(t, err)
+
+	event := &models.AuditEvent{
+		Time:     time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+		TenantID: "tenant",
+		Actor:    `user"name`,
+		Action:   ActionUserLoginFailed,
+		Target:   "id",
+	}
+
+	go sink.Write(event) // nolint:errcheck
+
+	conn, err := ln.Accept()
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	size, err := r.ReadString(' ')
+	assert.NoError(t, err)
+
+	n, err := strconv.Atoi(strings.TrimSpace(size))
+	assert.NoError(t, err)
+
+	msg := make([]byte, n)
+	_, err = io.ReadFull(r, msg)
+	assert.NoError(t, err)
+
+	// Facility 13 and warning severity for failures
+	assert.True(t, strings.HasPrefix(string(msg), "<108>1 2021-01-02T03:04:05Z "))
+	assert.Contains(t, string(msg), " user.login.failed [shellhub@32473 tenant=\"tenant\" actor=\"user\\\"name\" target=\"id\"] {")
+}
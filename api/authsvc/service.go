@@ -11,6 +11,7 @@ import (
 	"encoding/hex"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
@@ -18,18 +19,25 @@ import (
 
 	"github.com/cnf/structhash"
 	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/shellhub-io/shellhub/api/auditlog"
 	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/api/updatemngr"
 	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"gopkg.in/go-playground/validator.v9"
 )
 
+var ErrUnauthorized = errors.New("unauthorized")
+
 type Service interface {
 	AuthDevice(ctx context.Context, req *models.DeviceAuthRequest) (*models.DeviceAuthResponse, error)
 	AuthUser(ctx context.Context, req models.UserAuthRequest) (*models.UserAuthResponse, error)
 	AuthGetToken(ctx context.Context, tenant string) (*models.UserAuthResponse, error)
 	AuthPublicKey(ctx context.Context, req *models.PublicKeyAuthRequest) (*models.PublicKeyAuthResponse, error)
 	AuthSwapToken(ctx context.Context, ID, tenant string) (*models.UserAuthResponse, error)
+	AuthMQTTClient(ctx context.Context, query *models.MqttAuthQuery) error
+	AuthMQTTTopic(ctx context.Context, query *models.MqttACLQuery) error
 	PublicKey() *rsa.PublicKey
 }
 
@@ -69,6 +77,8 @@ func (s *service) AuthDevice(ctx context.Context, req *models.DeviceAuthRequest)
 	}
 	hostname := strings.ToLower(req.DeviceAuth.Hostname)
 
+	// The limit of devices is enforced when they are accepted, so devices
+	// always register as pending, e.g. to replace one with the same MAC
 	if err := s.store.AddDevice(ctx, device, hostname); err != nil {
 		return nil, err
 	}
@@ -105,11 +115,36 @@ func (s *service) AuthDevice(ctx context.Context, req *models.DeviceAuthRequest)
 		return nil, err
 	}
 
+	var current string
+	if req.Info != nil {
+		current = req.Info.Version
+	}
+
+	// Failing to pick the version must not keep the device from connecting,
+	// it is just not updated this time
+	updateVersion, err := updatemngr.NewService(s.store).TargetVersion(ctx, dev, namespace, current)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"uid": device.UID,
+			"err": err,
+		}).Error("Failed to get the target version of the device")
+
+		updateVersion = ""
+	}
+
+	auditlog.Emit(ctx, models.AuditEvent{
+		TenantID: device.TenantID,
+		Actor:    dev.Name,
+		Action:   auditlog.ActionDeviceAuth,
+		Target:   device.UID,
+	})
+
 	return &models.DeviceAuthResponse{
-		UID:       hex.EncodeToString(uid[:]),
-		Token:     tokenStr,
-		Name:      dev.Name,
-		Namespace: namespace.Name,
+		UID:           hex.EncodeToString(uid[:]),
+		Token:         tokenStr,
+		Name:          dev.Name,
+		Namespace:     namespace.Name,
+		UpdateVersion: updateVersion,
 	}, nil
 }
 
@@ -118,6 +153,12 @@ func (s *service) AuthUser(ctx context.Context, req models.UserAuthRequest) (*mo
 	if err != nil {
 		user, err = s.store.GetUserByEmail(ctx, strings.ToLower(req.Username))
 		if err != nil {
+			auditlog.Record(ctx, s.store, models.AuditEvent{
+				Actor:  req.Username,
+				Action: auditlog.ActionUserLoginFailed,
+				Target: req.Username,
+			})
+
 			return nil, err
 		}
 	}
@@ -151,6 +192,14 @@ func (s *service) AuthUser(ctx context.Context, req models.UserAuthRequest) (*mo
 		if err != nil {
 			return nil, err
 		}
+
+		auditlog.Record(ctx, s.store, models.AuditEvent{
+			TenantID: tenant,
+			Actor:    user.Username,
+			Action:   auditlog.ActionUserLogin,
+			Target:   user.ID,
+		})
+
 		return &models.UserAuthResponse{
 			Token:  tokenStr,
 			Name:   user.Name,
@@ -161,6 +210,13 @@ func (s *service) AuthUser(ctx context.Context, req models.UserAuthRequest) (*mo
 		}, nil
 	}
 
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: tenant,
+		Actor:    user.Username,
+		Action:   auditlog.ActionUserLoginFailed,
+		Target:   user.ID,
+	})
+
 	return nil, errors.New("unauthorized")
 }
 
@@ -276,6 +332,45 @@ func (s *service) AuthSwapToken(ctx context.Context, username, tenant string) (*
 	return nil, nil
 }
 
+// AuthMQTTClient authenticates a device connecting to the MQTT broker, which
+// uses its UID as username and its token as password.
+func (s *service) AuthMQTTClient(ctx context.Context, query *models.MqttAuthQuery) error {
+	claims := &models.DeviceAuthClaims{}
+
+	token, err := jwt.ParseWithClaims(query.Password, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, ErrUnauthorized
+		}
+
+		return s.pubKey, nil
+	})
+	if err != nil || !token.Valid || claims.Claims != "device" || claims.UID != query.Username {
+		return ErrUnauthorized
+	}
+
+	device, err := s.store.GetDevice(ctx, models.UID(claims.UID))
+	if err != nil || device.Status != "accepted" {
+		return ErrUnauthorized
+	}
+
+	return nil
+}
+
+// AuthMQTTTopic authorizes an authenticated device to publish or subscribe to
+// a topic, restricting it to the ones under device/<uid>/.
+func (s *service) AuthMQTTTopic(ctx context.Context, query *models.MqttACLQuery) error {
+	if query.Username == "" {
+		return ErrUnauthorized
+	}
+
+	prefix := fmt.Sprintf("device/%s", query.Username)
+	if query.Topic != prefix && !strings.HasPrefix(query.Topic, prefix+"/") {
+		return ErrUnauthorized
+	}
+
+	return nil
+}
+
 func (s *service) PublicKey() *rsa.PublicKey {
 	return s.pubKey
 }
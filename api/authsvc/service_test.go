@@ -6,14 +6,17 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/cnf/structhash"
+	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/api/store/mocks"
 	"github.com/shellhub-io/shellhub/pkg/models"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 	"github.com/undefinedlabs/go-mpatch"
 )
 
@@ -46,7 +49,8 @@ func TestAuthDevice(t *testing.T) {
 		LastSeen: now,
 	}
 
-	namespace := &models.Namespace{Name: "group1", Owner: "hash1", TenantID: "tenant"}
+	// New devices register as pending even when the namespace is full
+	namespace := &models.Namespace{Name: "group1", Owner: "hash1", TenantID: "tenant", MaxDevices: 3, DevicesCount: 3}
 
 	mock.On("AddDevice", ctx, *device, "").
 		Return(nil).Once()
@@ -58,6 +62,9 @@ func TestAuthDevice(t *testing.T) {
 		Return(device, nil).Once()
 	mock.On("GetNamespace", ctx, namespace.TenantID).
 		Return(namespace, nil).Once()
+	// Failing to get the target version does not fail the authentication
+	mock.On("GetUpdateChannel", ctx, "stable").
+		Return(nil, errors.New("connection lost")).Once()
 
 	// Mock time.Now using monkey patch
 	patch, err := mpatch.PatchMethod(time.Now, func() time.Time { return now })
@@ -71,6 +78,7 @@ func TestAuthDevice(t *testing.T) {
 	assert.Equal(t, device.Name, authRes.Name)
 	assert.Equal(t, namespace.Name, authRes.Namespace)
 	assert.NotEmpty(t, authRes.Token)
+	assert.Empty(t, authRes.UpdateVersion)
 
 	mock.AssertExpectations(t)
 }
@@ -104,6 +112,7 @@ func TestAuthUser(t *testing.T) {
 		Return(user, nil).Once()
 	mock.On("GetSomeNamespace", ctx, user.ID).
 		Return(namespace, nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	authRes, err := s.AuthUser(ctx, *authReq)
 	assert.NoError(t, err)
@@ -114,3 +123,64 @@ func TestAuthUser(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func TestAuthMQTTClient(t *testing.T) {
+	mock := &mocks.Store{}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	s := NewService(store.Store(mock), privateKey, &privateKey.PublicKey)
+
+	ctx := context.TODO()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, models.DeviceAuthClaims{
+		UID:        "uid",
+		AuthClaims: models.AuthClaims{Claims: "device"},
+	}).SignedString(privateKey)
+	assert.NoError(t, err)
+
+	mock.On("GetDevice", ctx, models.UID("uid")).
+		Return(&models.Device{UID: "uid", Status: "accepted"}, nil).Once()
+
+	err = s.AuthMQTTClient(ctx, &models.MqttAuthQuery{Username: "uid", Password: token})
+	assert.NoError(t, err)
+
+	// The token belongs to other device
+	err = s.AuthMQTTClient(ctx, &models.MqttAuthQuery{Username: "other", Password: token})
+	assert.Equal(t, ErrUnauthorized, err)
+
+	err = s.AuthMQTTClient(ctx, &models.MqttAuthQuery{Username: "uid", Password: "invalid"})
+	assert.Equal(t, ErrUnauthorized, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestAuthMQTTTopic(t *testing.T) {
+	mock := &mocks.Store{}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	s := NewService(store.Store(mock), privateKey, &privateKey.PublicKey)
+
+	ctx := context.TODO()
+
+	cases := []struct {
+		topic string
+		err   error
+	}{
+		{"device/uid", nil},
+		{"device/uid/events", nil},
+		{"device/uid/#", nil},
+		{"device/other/events", ErrUnauthorized},
+		{"device/uid2/events", ErrUnauthorized},
+		{"device/#", ErrUnauthorized},
+		{"#", ErrUnauthorized},
+	}
+
+	for _, tc := range cases {
+		err := s.AuthMQTTTopic(ctx, &models.MqttACLQuery{Username: "uid", Topic: tc.topic, Access: "1"})
+		assert.Equal(t, tc.err, err, tc.topic)
+	}
+}
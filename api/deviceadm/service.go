@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"errors"
 	"strings"
+	"time"
 
+	"github.com/shellhub-io/shellhub/api/auditlog"
 	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
@@ -14,6 +16,7 @@ import (
 )
 
 var ErrUnauthorized = errors.New("unauthorized")
+var ErrMaxDevicesReached = errors.New("namespace reached the maximum number of devices")
 
 type Service interface {
 	ListDevices(ctx context.Context, pagination paginator.Query, filter string, status string, sort string, order string) ([]models.Device, int, error)
@@ -22,6 +25,8 @@ type Service interface {
 	RenameDevice(ctx context.Context, uid models.UID, name, tenant, username string) error
 	LookupDevice(ctx context.Context, namespace, name string) (*models.Device, error)
 	UpdateDeviceStatus(ctx context.Context, uid models.UID, online bool) error
+	SetDevicePresence(ctx context.Context, uid models.UID, online bool, at time.Time) error
+	SetMQTTPresence(ctx context.Context, uid models.UID, online bool, at time.Time) error
 	UpdatePendingStatus(ctx context.Context, uid models.UID, status, tenant, username string) error
 }
 
@@ -53,13 +58,13 @@ func (s *service) isNamespaceOnwer(ctx context.Context, tenant, username string)
 func (s *service) ListDevices(ctx context.Context, pagination paginator.Query, filterB64 string, status string, sort string, order string) ([]models.Device, int, error) {
 	raw, err := base64.StdEncoding.DecodeString(filterB64)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, store.ErrInvalidFilter
 	}
 
 	var filter []models.Filter
 
 	if err := json.Unmarshal([]byte(raw), &filter); len(raw) > 0 && err != nil {
-		return nil, 0, err
+		return nil, 0, store.ErrInvalidFilter
 	}
 
 	return s.store.ListDevices(ctx, pagination, filter, status, sort, order)
@@ -77,7 +82,19 @@ func (s *service) DeleteDevice(ctx context.Context, uid models.UID, tenant, user
 
 	device, _ := s.store.GetDeviceByUID(ctx, uid, tenant)
 	if device != nil {
-		return s.store.DeleteDevice(ctx, uid)
+		if err := s.store.DeleteDevice(ctx, uid); err != nil {
+			return err
+		}
+
+		auditlog.Record(ctx, s.store, models.AuditEvent{
+			TenantID: tenant,
+			Actor:    username,
+			Action:   auditlog.ActionDeviceDelete,
+			Target:   string(uid),
+			Before:   device.Name,
+		})
+
+		return nil
 	}
 	return ErrUnauthorized
 }
@@ -93,11 +110,25 @@ func (s *service) RenameDevice(ctx context.Context, uid models.UID, name, tenant
 	name = strings.ToLower(name)
 	if device != nil {
 		if device.Name != name {
+			oldName := device.Name
 			device.Name = name
 			if err := validate.Struct(device); err == nil {
 				otherDevice, _ := s.store.GetDeviceByName(ctx, name, tenant)
 				if otherDevice == nil {
-					return s.store.RenameDevice(ctx, uid, name)
+					if err := s.store.RenameDevice(ctx, uid, name); err != nil {
+						return err
+					}
+
+					auditlog.Record(ctx, s.store, models.AuditEvent{
+						TenantID: tenant,
+						Actor:    username,
+						Action:   auditlog.ActionDeviceRename,
+						Target:   string(uid),
+						Before:   oldName,
+						After:    name,
+					})
+
+					return nil
 				}
 			}
 		}
@@ -113,6 +144,34 @@ func (s *service) UpdateDeviceStatus(ctx context.Context, uid models.UID, online
 	return s.store.UpdateDeviceStatus(ctx, uid, online)
 }
 
+// SetDevicePresence updates the online status of a device as reported by the
+// gateway holding its tunnel, recording when it connected or disconnected.
+func (s *service) SetDevicePresence(ctx context.Context, uid models.UID, online bool, at time.Time) error {
+	if err := s.store.UpdateDeviceStatus(ctx, uid, online); err != nil {
+		return err
+	}
+
+	return s.store.SetDevicePresence(ctx, uid, online, at)
+}
+
+// SetMQTTPresence updates the online status of a device as reported by the
+// MQTT broker. A device holding a tunnel is kept online when its MQTT client
+// disconnects.
+func (s *service) SetMQTTPresence(ctx context.Context, uid models.UID, online bool, at time.Time) error {
+	if !online {
+		_, err := s.store.GetTunnel(ctx, string(uid))
+		if err == nil {
+			return nil
+		}
+
+		if err != store.ErrTunnelNotFound {
+			return err
+		}
+	}
+
+	return s.SetDevicePresence(ctx, uid, online, at)
+}
+
 func (s *service) UpdatePendingStatus(ctx context.Context, uid models.UID, status, tenant, username string) error {
 	err := s.isNamespaceOnwer(ctx, tenant, username)
 	if err != nil {
@@ -122,9 +181,26 @@ func (s *service) UpdatePendingStatus(ctx context.Context, uid models.UID, statu
 	device, _ := s.store.GetDeviceByUID(ctx, uid, tenant)
 	if device != nil {
 		if status == "accepted" {
-			sameMacDev, _ := s.store.GetDeviceByMac(ctx, device.Identity.MAC, device.TenantID, "accepted")
+			sameMacDev, err := s.store.GetDeviceByMac(ctx, device.Identity.MAC, device.TenantID, "accepted")
+			if err != nil && err != store.ErrDeviceNotFound {
+				return err
+			}
+
+			// A device replacing one with the same MAC address takes its
+			// place, so it does not count against the limit
+			replaces := sameMacDev != nil && sameMacDev.UID != device.UID
+			if !replaces && device.Status != "accepted" {
+				namespace, err := s.store.GetNamespace(ctx, tenant)
+				if err != nil {
+					return err
+				}
 
-			if sameMacDev != nil && sameMacDev.UID != device.UID {
+				if namespace.HasMaxDevicesReached() {
+					return ErrMaxDevicesReached
+				}
+			}
+
+			if replaces {
 				if err := s.store.UpdateUID(ctx, models.UID(sameMacDev.UID), models.UID(device.UID)); err != nil {
 					return err
 				}
@@ -136,7 +212,20 @@ func (s *service) UpdatePendingStatus(ctx context.Context, uid models.UID, statu
 				}
 			}
 		}
-		return s.store.UpdatePendingStatus(ctx, uid, status)
+		if err := s.store.UpdatePendingStatus(ctx, uid, status); err != nil {
+			return err
+		}
+
+		auditlog.Record(ctx, s.store, models.AuditEvent{
+			TenantID: tenant,
+			Actor:    username,
+			Action:   auditlog.ActionDeviceStatus,
+			Target:   string(uid),
+			Before:   device.Status,
+			After:    status,
+		})
+
+		return nil
 	}
 	return ErrUnauthorized
 }
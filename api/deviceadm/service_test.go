@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/api/store/mocks"
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 )
 
 func TestListDevices(t *testing.T) {
@@ -82,6 +85,7 @@ func TestDeleteDevice(t *testing.T) {
 		Return(device, nil).Once()
 	mock.On("DeleteDevice", ctx, models.UID(device.UID)).
 		Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	err := s.DeleteDevice(ctx, models.UID(device.UID), device.TenantID, user.Username)
 	assert.NoError(t, err)
@@ -121,6 +125,7 @@ func TestRenameDevice(t *testing.T) {
 		Return(nil, nil).Once()
 	mock.On("RenameDevice", ctx, models.UID(device.UID), renamedDevice.Name).
 		Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	err := s.RenameDevice(ctx, models.UID(device.UID), renamedDevice.Name, device.TenantID, user.Username)
 	assert.NoError(t, err)
@@ -172,6 +177,51 @@ func TestUpdateDeviceStatus(t *testing.T) {
 	mock.AssertExpectations(t)
 }
 
+func TestSetDevicePresence(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+	at := time.Now()
+
+	mock.On("UpdateDeviceStatus", ctx, models.UID("uid"), false).
+		Return(nil).Once()
+	mock.On("SetDevicePresence", ctx, models.UID("uid"), false, at).
+		Return(nil).Once()
+
+	err := s.SetDevicePresence(ctx, "uid", false, at)
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestSetMQTTPresence(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+	at := time.Now()
+
+	// The device is still connected through its tunnel
+	mock.On("GetTunnel", ctx, "uid").
+		Return(&models.Tunnel{UID: "uid", Instance: "gateway"}, nil).Once()
+
+	err := s.SetMQTTPresence(ctx, "uid", false, at)
+	assert.NoError(t, err)
+
+	mock.On("GetTunnel", ctx, "uid").
+		Return(nil, store.ErrTunnelNotFound).Once()
+	mock.On("UpdateDeviceStatus", ctx, models.UID("uid"), false).
+		Return(nil).Once()
+	mock.On("SetDevicePresence", ctx, models.UID("uid"), false, at).
+		Return(nil).Once()
+
+	err = s.SetMQTTPresence(ctx, "uid", false, at)
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
 func TestUpdatePendingStatus(t *testing.T) {
 	mock := &mocks.Store{}
 	s := NewService(store.Store(mock))
@@ -199,6 +249,7 @@ func TestUpdatePendingStatus(t *testing.T) {
 		Return(nil).Once()
 	mock.On("UpdatePendingStatus", ctx, models.UID(device.UID), "accepted").
 		Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	err := s.UpdatePendingStatus(ctx, models.UID("uid"), "accepted", "tenant", user.Username)
 	assert.NoError(t, err)
@@ -216,3 +267,35 @@ func TestUpdatePendingStatus(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func TestUpdatePendingStatusMaxDevicesReached(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	user := &models.User{Name: "name", Email: "", Username: "username", ID: "id"}
+	namespace := &models.Namespace{Name: "group1", Owner: "id", TenantID: "tenant", MaxDevices: 3, DevicesCount: 3}
+	identity := &models.DeviceIdentity{MAC: "mac"}
+	device := &models.Device{UID: "uid", Name: "name", TenantID: "tenant", Identity: identity, Status: "pending"}
+	ctx := context.TODO()
+
+	// Failures other than not finding a device with the same MAC are
+	// reported instead of counting the device against the limit
+	mock.On("GetUserByUsername", ctx, user.Username).
+		Return(user, nil).Twice()
+	mock.On("GetNamespace", ctx, device.TenantID).
+		Return(namespace, nil).Times(3)
+	mock.On("GetDeviceByUID", ctx, models.UID(device.UID), device.TenantID).
+		Return(device, nil).Twice()
+	mock.On("GetDeviceByMac", ctx, "mac", device.TenantID, "accepted").
+		Return(nil, errors.New("connection lost")).Once()
+	mock.On("GetDeviceByMac", ctx, "mac", device.TenantID, "accepted").
+		Return(nil, store.ErrDeviceNotFound).Once()
+
+	err := s.UpdatePendingStatus(ctx, models.UID("uid"), "accepted", "tenant", user.Username)
+	assert.EqualError(t, err, "connection lost")
+
+	err = s.UpdatePendingStatus(ctx, models.UID("uid"), "accepted", "tenant", user.Username)
+	assert.Equal(t, ErrMaxDevicesReached, err)
+
+	mock.AssertExpectations(t)
+}
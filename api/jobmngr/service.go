@@ -0,0 +1,295 @@
+package jobmngr
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/shellhub-io/shellhub/api/auditlog"
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/pkg/api/paginator"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// GatewayAddress is the address of the SSH gateway, which runs the jobs
+// on the devices through their tunnel.
+var GatewayAddress = "ssh:8080"
+
+const (
+	// DefaultTimeout is how long in seconds a command may run on each device
+	// when no timeout is given.
+	DefaultTimeout = 300
+	// MaxTimeout is the longest timeout in seconds allowed.
+	MaxTimeout = 3600
+)
+
+var (
+	ErrInvalidJob    = errors.New("invalid job")
+	ErrInvalidResult = errors.New("invalid job result")
+	ErrNoDevices     = errors.New("no devices match the filter")
+	ErrJobNotFound   = errors.New("job not found")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrResultStarted = errors.New("job already started on the device")
+)
+
+type Service interface {
+	CreateJob(ctx context.Context, job *models.Job, tenant, username string) (*models.Job, error)
+	GetJob(ctx context.Context, uid string) (*models.Job, error)
+	ListJobs(ctx context.Context, pagination paginator.Query) ([]models.Job, int, error)
+	ListResults(ctx context.Context, uid, status string, pagination paginator.Query) ([]models.JobResult, int, error)
+	ListWaitingResults(ctx context.Context, device string) ([]models.JobResult, error)
+	StartResult(ctx context.Context, job, device, status string) error
+	SetResult(ctx context.Context, result *models.JobResult) error
+}
+
+type service struct {
+	store store.Store
+}
+
+func NewService(store store.Store) Service {
+	return &service{store}
+}
+
+// CreateJob runs a command on the accepted devices of the namespace matching
+// the filter of the job. Devices offline are either skipped or, when the job
+// is retried, run it once they come back. Only the owner of the namespace
+// may run jobs, as the devices are signed in to without their credentials.
+func (s *service) CreateJob(ctx context.Context, job *models.Job, tenant, username string) (*models.Job, error) {
+	ns, _ := s.store.GetNamespace(ctx, tenant)
+	user, _ := s.store.GetUserByUsername(ctx, username)
+	if ns == nil || user == nil || ns.Owner != user.ID {
+		return nil, ErrUnauthorized
+	}
+
+	job.Command = strings.TrimSpace(job.Command)
+	job.User = strings.TrimSpace(job.User)
+	if job.Command == "" || job.User == "" || job.Timeout < 0 || job.Timeout > MaxTimeout {
+		return nil, ErrInvalidJob
+	}
+
+	if job.Timeout == 0 {
+		job.Timeout = DefaultTimeout
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(job.Filter)
+	if err != nil {
+		return nil, ErrInvalidJob
+	}
+
+	var filter []models.Filter
+
+	if err := json.Unmarshal(raw, &filter); len(raw) > 0 && err != nil {
+		return nil, ErrInvalidJob
+	}
+
+	devices, _, err := s.store.ListDevices(ctx, paginator.Query{Page: 1, PerPage: -1}, filter, "accepted", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	job.UID = uuid.Must(uuid.NewV4(), nil).String()
+	job.TenantID = tenant
+	job.Status = models.JobStatusRunning
+	job.CreatedBy = username
+	job.CreatedAt = time.Now()
+	job.FinishedAt = nil
+
+	var online []string
+	results := make([]models.JobResult, 0, len(devices))
+
+	for _, device := range devices {
+		if device.TenantID != tenant {
+			continue
+		}
+
+		result := models.JobResult{
+			JobUID:    job.UID,
+			DeviceUID: device.UID,
+			TenantID:  tenant,
+		}
+
+		switch {
+		case device.Online:
+			result.Status = models.JobResultPending
+			online = append(online, device.UID)
+		case job.Retry:
+			result.Status = models.JobResultWaiting
+		default:
+			result.Status = models.JobResultSkipped
+		}
+
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return nil, ErrNoDevices
+	}
+
+	if len(online) == 0 && !job.Retry {
+		now := time.Now()
+		job.Status = models.JobStatusFinished
+		job.FinishedAt = &now
+	}
+
+	if err := s.store.CreateJob(ctx, job, results); err != nil {
+		return nil, err
+	}
+
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: tenant,
+		Actor:    username,
+		Action:   auditlog.ActionJobCreate,
+		Target:   job.UID,
+		After: map[string]interface{}{
+			"command": job.Command,
+			"user":    job.User,
+			"devices": len(results),
+		},
+	})
+
+	if len(online) > 0 {
+		if err := dispatch(ctx, job, online); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"job": job.UID,
+				"err": err,
+			}).Error("Failed to dispatch job to the gateway")
+
+			for _, uid := range online {
+				result := &models.JobResult{
+					JobUID:    job.UID,
+					DeviceUID: uid,
+					Status:    models.JobResultFailed,
+					Error:     "failed to dispatch job to the gateway",
+				}
+
+				if err := s.SetResult(ctx, result); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return job, nil
+}
+
+func (s *service) GetJob(ctx context.Context, uid string) (*models.Job, error) {
+	job, err := s.store.GetJob(ctx, uid)
+	if err == store.ErrJobNotFound {
+		return nil, ErrJobNotFound
+	}
+
+	return job, err
+}
+
+func (s *service) ListJobs(ctx context.Context, pagination paginator.Query) ([]models.Job, int, error) {
+	return s.store.ListJobs(ctx, pagination)
+}
+
+func (s *service) ListResults(ctx context.Context, uid, status string, pagination paginator.Query) ([]models.JobResult, int, error) {
+	if _, err := s.GetJob(ctx, uid); err != nil {
+		return nil, 0, err
+	}
+
+	var statuses []string
+	if status != "" {
+		statuses = []string{status}
+	}
+
+	return s.store.ListJobResults(ctx, uid, statuses, pagination)
+}
+
+// ListWaitingResults lists the jobs a device must run once it comes back.
+func (s *service) ListWaitingResults(ctx context.Context, device string) ([]models.JobResult, error) {
+	return s.store.ListDeviceJobResults(ctx, device, models.JobResultWaiting)
+}
+
+// StartResult marks the job as running on the device, if it is still in the
+// given status. It fails when the job was already started on the device, so
+// that it is run only once.
+func (s *service) StartResult(ctx context.Context, job, device, status string) error {
+	if status != models.JobResultPending && status != models.JobResultWaiting {
+		return ErrInvalidResult
+	}
+
+	if err := s.store.StartJobResult(ctx, job, device, status, time.Now()); err != nil {
+		if err == store.ErrJobNotFound {
+			return ErrResultStarted
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// SetResult updates the result of a job on a device, finishing the job once
+// every device is done.
+func (s *service) SetResult(ctx context.Context, result *models.JobResult) error {
+	switch result.Status {
+	case models.JobResultWaiting, models.JobResultSuccess, models.JobResultFailed, models.JobResultSkipped:
+	default:
+		return ErrInvalidResult
+	}
+
+	if err := s.store.UpdateJobResult(ctx, result); err != nil {
+		if err == store.ErrJobNotFound {
+			return ErrJobNotFound
+		}
+
+		return err
+	}
+
+	if !result.Finished() {
+		return nil
+	}
+
+	statuses := []string{models.JobResultPending, models.JobResultWaiting, models.JobResultRunning}
+
+	_, count, err := s.store.ListJobResults(ctx, result.JobUID, statuses, paginator.Query{Page: 1, PerPage: 1})
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	return s.store.FinishJob(ctx, result.JobUID, time.Now())
+}
+
+func dispatch(ctx context.Context, job *models.Job, devices []string) error {
+	body, err := json.Marshal(&models.JobDispatch{Job: *job, Devices: devices})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/internal/jobs/%s", GatewayAddress, job.UID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to dispatch job: %s", res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package jobmngr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/api/store/mocks"
+	"github.com/shellhub-io/shellhub/pkg/api/paginator"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+)
+
+func TestCreateJob(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	var dispatched models.JobDispatch
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&dispatched))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer gateway.Close()
+
+	GatewayAddress = strings.TrimPrefix(gateway.URL, "http://")
+
+	namespace := &models.Namespace{TenantID: "tenant", Owner: "owner"}
+	owner := &models.User{ID: "owner", Username: "user"}
+	member := &models.User{ID: "member", Username: "member"}
+
+	mock.On("GetNamespace", ctx, "tenant").Return(namespace, nil)
+	mock.On("GetUserByUsername", ctx, "user").Return(owner, nil)
+	mock.On("GetUserByUsername", ctx, "member").Return(member, nil).Once()
+
+	_, err := s.CreateJob(ctx, &models.Job{Command: "uptime", User: "root"}, "tenant", "member")
+	assert.Equal(t, ErrUnauthorized, err)
+
+	_, err = s.CreateJob(ctx, &models.Job{Command: " ", User: "root"}, "tenant", "user")
+	assert.Equal(t, ErrInvalidJob, err)
+
+	_, err = s.CreateJob(ctx, &models.Job{Command: "uptime"}, "tenant", "user")
+	assert.Equal(t, ErrInvalidJob, err)
+
+	_, err = s.CreateJob(ctx, &models.Job{Command: "uptime", User: "root", Timeout: MaxTimeout + 1}, "tenant", "user")
+	assert.Equal(t, ErrInvalidJob, err)
+
+	devices := []models.Device{
+		{UID: "online", TenantID: "tenant", Online: true},
+		{UID: "offline", TenantID: "tenant", Online: false},
+		{UID: "other", TenantID: "other", Online: true},
+	}
+
+	results := []models.JobResult{
+		{DeviceUID: "online", TenantID: "tenant", Status: models.JobResultPending},
+		{DeviceUID: "offline", TenantID: "tenant", Status: models.JobResultSkipped},
+	}
+
+	query := paginator.Query{Page: 1, PerPage: -1}
+
+	mock.On("ListDevices", ctx, query, []models.Filter(nil), "accepted", "", "").Return(devices, len(devices), nil).Once()
+	mock.On("CreateJob", ctx, testifymock.AnythingOfType("*models.Job"), testifymock.AnythingOfType("[]models.JobResult")).
+		Run(func(args testifymock.Arguments) {
+			job := args.Get(1).(*models.Job)
+			for i := range results {
+				results[i].JobUID = job.UID
+			}
+
+			assert.Equal(t, results, args.Get(2).([]models.JobResult))
+		}).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+
+	job, err := s.CreateJob(ctx, &models.Job{Command: "uptime", User: "root"}, "tenant", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "root", job.User)
+	assert.Equal(t, DefaultTimeout, job.Timeout)
+	assert.Equal(t, models.JobStatusRunning, job.Status)
+	assert.Equal(t, "user", job.CreatedBy)
+	assert.Equal(t, job.UID, dispatched.Job.UID)
+	assert.Equal(t, []string{"online"}, dispatched.Devices)
+
+	mock.On("ListDevices", ctx, query, []models.Filter(nil), "accepted", "", "").Return(devices[2:], 1, nil).Once()
+
+	_, err = s.CreateJob(ctx, &models.Job{Command: "uptime", User: "root"}, "tenant", "user")
+	assert.Equal(t, ErrNoDevices, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestSetResult(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	statuses := []string{models.JobResultPending, models.JobResultWaiting, models.JobResultRunning}
+	query := paginator.Query{Page: 1, PerPage: 1}
+
+	err := s.SetResult(ctx, &models.JobResult{JobUID: "job", DeviceUID: "device1", Status: models.JobResultPending})
+	assert.Equal(t, ErrInvalidResult, err)
+
+	err = s.SetResult(ctx, &models.JobResult{JobUID: "job", DeviceUID: "device1", Status: models.JobResultRunning})
+	assert.Equal(t, ErrInvalidResult, err)
+
+	first := &models.JobResult{JobUID: "job", DeviceUID: "device1", Status: models.JobResultSuccess}
+	last := &models.JobResult{JobUID: "job", DeviceUID: "device2", Status: models.JobResultFailed}
+
+	mock.On("UpdateJobResult", ctx, first).Return(nil).Once()
+	mock.On("ListJobResults", ctx, "job", statuses, query).Return([]models.JobResult{*last}, 1, nil).Once()
+
+	err = s.SetResult(ctx, first)
+	assert.NoError(t, err)
+
+	mock.On("UpdateJobResult", ctx, last).Return(nil).Once()
+	mock.On("ListJobResults", ctx, "job", statuses, query).Return([]models.JobResult{}, 0, nil).Once()
+	mock.On("FinishJob", ctx, "job", testifymock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	err = s.SetResult(ctx, last)
+	assert.NoError(t, err)
+
+	other := &models.JobResult{JobUID: "other", DeviceUID: "device1", Status: models.JobResultSuccess}
+
+	mock.On("UpdateJobResult", ctx, other).Return(store.ErrJobNotFound).Once()
+
+	err = s.SetResult(ctx, other)
+	assert.Equal(t, ErrJobNotFound, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestStartResult(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	err := s.StartResult(ctx, "job", "device", models.JobResultRunning)
+	assert.Equal(t, ErrInvalidResult, err)
+
+	mock.On("StartJobResult", ctx, "job", "device", models.JobResultWaiting, testifymock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	err = s.StartResult(ctx, "job", "device", models.JobResultWaiting)
+	assert.NoError(t, err)
+
+	mock.On("StartJobResult", ctx, "job", "device", models.JobResultWaiting, testifymock.AnythingOfType("time.Time")).Return(store.ErrJobNotFound).Once()
+
+	err = s.StartResult(ctx, "job", "device", models.JobResultWaiting)
+	assert.Equal(t, ErrResultStarted, err)
+
+	mock.AssertExpectations(t)
+}
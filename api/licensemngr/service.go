@@ -0,0 +1,154 @@
+package licensemngr
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/pkg/models"
+)
+
+// PublicKey is the base64 encoded Ed25519 key the licenses are signed with.
+// It is injected using `-ldflags` build option
+// (e.g: `go build -ldflags "-X github.com/shellhub-io/shellhub/api/licensemngr.PublicKey=..."`).
+var PublicKey string
+
+var (
+	ErrInvalidLicense = errors.New("invalid license")
+	ErrLicenseExpired = errors.New("license expired")
+)
+
+type Service interface {
+	GetLicense(ctx context.Context) (*models.LicenseInfo, error)
+	SetLicense(ctx context.Context, data []byte) (*models.LicenseInfo, error)
+	Features(ctx context.Context) (*models.Features, error)
+	HasFeature(ctx context.Context, feature string) bool
+	NamespaceMaxDevices(ctx context.Context) int
+}
+
+type service struct {
+	store store.Store
+}
+
+func NewService(store store.Store) Service {
+	return &service{store}
+}
+
+// GetLicense returns the last license uploaded, which may be expired.
+func (s *service) GetLicense(ctx context.Context) (*models.LicenseInfo, error) {
+	license, err := s.store.LoadLicense(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(license.RawData)
+}
+
+// SetLicense verifies and stores a license, replacing the current one.
+func (s *service) SetLicense(ctx context.Context, data []byte) (*models.LicenseInfo, error) {
+	data = bytes.TrimSpace(data)
+
+	info, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Expired(time.Now()) {
+		return nil, ErrLicenseExpired
+	}
+
+	if err := s.store.SaveLicense(ctx, &models.License{RawData: data, CreatedAt: time.Now()}); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// Features returns the features enabled by the current license, none without
+// a valid one.
+func (s *service) Features(ctx context.Context) (*models.Features, error) {
+	features := &models.Features{Features: []string{}}
+
+	info, err := s.validLicense(ctx)
+	if err != nil {
+		if err == store.ErrLicenseNotFound || err == ErrInvalidLicense || err == ErrLicenseExpired {
+			return features, nil
+		}
+
+		return nil, err
+	}
+
+	features.Features = append(features.Features, info.Features...)
+
+	return features, nil
+}
+
+func (s *service) HasFeature(ctx context.Context, feature string) bool {
+	info, err := s.validLicense(ctx)
+	if err != nil {
+		return false
+	}
+
+	return info.HasFeature(feature)
+}
+
+// NamespaceMaxDevices returns the number of devices allowed in the namespaces
+// created, -1 meaning unlimited.
+func (s *service) NamespaceMaxDevices(ctx context.Context) int {
+	info, err := s.validLicense(ctx)
+	if err != nil || info.MaxDevices <= 0 {
+		return -1
+	}
+
+	return info.MaxDevices
+}
+
+func (s *service) validLicense(ctx context.Context) (*models.LicenseInfo, error) {
+	info, err := s.GetLicense(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Expired(time.Now()) {
+		return nil, ErrLicenseExpired
+	}
+
+	return info, nil
+}
+
+// Parse verifies the signature of a license and returns its content. A
+// license is made of its JSON encoded content and the Ed25519 signature of
+// it, both base64url encoded and joined by a dot.
+func Parse(data []byte) (*models.LicenseInfo, error) {
+	key, err := base64.StdEncoding.DecodeString(PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, ErrInvalidLicense
+	}
+
+	parts := bytes.Split(bytes.TrimSpace(data), []byte("."))
+	if len(parts) != 2 {
+		return nil, ErrInvalidLicense
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(string(parts[0]))
+	if err != nil {
+		return nil, ErrInvalidLicense
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(string(parts[1]))
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(key), payload, signature) {
+		return nil, ErrInvalidLicense
+	}
+
+	info := &models.LicenseInfo{}
+	if err := json.Unmarshal(payload, info); err != nil {
+		return nil, ErrInvalidLicense
+	}
+
+	return info, nil
+}
@@ -0,0 +1,118 @@
+package licensemngr
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/api/store/mocks"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+)
+
+func signLicense(t *testing.T, key ed25519.PrivateKey, info *models.LicenseInfo) []byte {
+	payload, err := json.Marshal(info)
+	assert.NoError(t, err)
+
+	signature := ed25519.Sign(key, payload)
+
+	return []byte(base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signature))
+}
+
+func generateKey(t *testing.T) ed25519.PrivateKey {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	PublicKey = base64.StdEncoding.EncodeToString(pub)
+
+	return priv
+}
+
+func TestSetLicense(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	key := generateKey(t)
+
+	info := &models.LicenseInfo{
+		ID:         "id",
+		Customer:   "customer",
+		Features:   []string{models.FeatureFirewall},
+		MaxDevices: 3,
+		ExpiresAt:  time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+	}
+
+	mock.On("SaveLicense", ctx, testifymock.AnythingOfType("*models.License")).Return(nil).Once()
+
+	license, err := s.SetLicense(ctx, signLicense(t, key, info))
+	assert.NoError(t, err)
+	assert.Equal(t, info, license)
+
+	// Signed by other key
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	_, err = s.SetLicense(ctx, signLicense(t, other, info))
+	assert.Equal(t, ErrInvalidLicense, err)
+
+	expired := *info
+	expired.ExpiresAt = time.Now().Add(-time.Hour)
+
+	_, err = s.SetLicense(ctx, signLicense(t, key, &expired))
+	assert.Equal(t, ErrLicenseExpired, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestFeatures(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	key := generateKey(t)
+
+	valid := &models.LicenseInfo{
+		ID:         "id",
+		Features:   []string{models.FeatureFirewall},
+		MaxDevices: 3,
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+
+	expired := &models.LicenseInfo{
+		ID:         "id",
+		Features:   []string{models.FeatureFirewall},
+		MaxDevices: 3,
+		ExpiresAt:  time.Now().Add(-time.Hour),
+	}
+
+	mock.On("LoadLicense", ctx).Return(&models.License{RawData: signLicense(t, key, valid)}, nil).Times(3)
+
+	features, err := s.Features(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Features{Features: []string{models.FeatureFirewall}}, features)
+	assert.True(t, s.HasFeature(ctx, models.FeatureFirewall))
+	assert.Equal(t, 3, s.NamespaceMaxDevices(ctx))
+
+	mock.On("LoadLicense", ctx).Return(&models.License{RawData: signLicense(t, key, expired)}, nil).Times(3)
+
+	features, err = s.Features(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, features.Features)
+	assert.False(t, s.HasFeature(ctx, models.FeatureFirewall))
+	assert.Equal(t, -1, s.NamespaceMaxDevices(ctx))
+
+	mock.On("LoadLicense", ctx).Return(nil, store.ErrLicenseNotFound).Once()
+
+	assert.Equal(t, -1, s.NamespaceMaxDevices(ctx))
+
+	mock.AssertExpectations(t)
+}
@@ -3,14 +3,20 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/auditlog"
+	"github.com/shellhub-io/shellhub/api/licensemngr"
+	"github.com/shellhub-io/shellhub/api/metrics"
 	"github.com/shellhub-io/shellhub/api/routes"
 	"github.com/shellhub-io/shellhub/api/routes/middlewares"
+	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/api/store/mongo"
+	"github.com/sirupsen/logrus"
 	mgo "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -18,11 +24,25 @@ import (
 type config struct {
 	MongoHost string `envconfig:"mongo_host" default:"mongo"`
 	MongoPort int    `envconfig:"mongo_port" default:"27017"`
+
+	// Syslog server to stream audit events to (tcp://host:port or udp://host:port)
+	AuditSyslogAddress string `envconfig:"audit_syslog_address"`
+	// File to append audit events to as JSON lines
+	AuditFile string `envconfig:"audit_file"`
+	// Size in bytes after which the audit file is rotated
+	AuditFileMaxSize int64 `envconfig:"audit_file_max_size" default:"104857600"`
+	// Number of rotated audit files to keep
+	AuditFileMaxBackups int `envconfig:"audit_file_max_backups" default:"5"`
+
+	// Domain the HTTP services of the devices are proxied at, which the
+	// token cookie is set for
+	DeviceProxyDomain string `envconfig:"device_proxy_domain"`
 }
 
 func main() {
 	e := echo.New()
 	e.Use(middleware.Logger())
+	e.Use(metrics.Middleware())
 
 	var cfg config
 	if err := envconfig.Process("api", &cfg); err != nil {
@@ -30,7 +50,8 @@ func main() {
 	}
 
 	// Set client options
-	clientOptions := options.Client().ApplyURI(fmt.Sprintf("mongodb://%s:%d", cfg.MongoHost, cfg.MongoPort))
+	clientOptions := options.Client().ApplyURI(fmt.Sprintf("mongodb://%s:%d", cfg.MongoHost, cfg.MongoPort)).
+		SetMonitor(metrics.CommandMonitor())
 	// Connect to MongoDB
 	client, err := mgo.Connect(context.TODO(), clientOptions)
 	if err != nil {
@@ -46,6 +67,28 @@ func main() {
 		panic(err)
 	}
 
+	if cfg.AuditSyslogAddress != "" {
+		sink, err := auditlog.NewSyslogSink(cfg.AuditSyslogAddress)
+		if err != nil {
+			panic(err)
+		}
+
+		auditlog.RegisterSink(sink)
+	}
+
+	if cfg.AuditFile != "" {
+		sink, err := auditlog.NewFileSink(cfg.AuditFile, cfg.AuditFileMaxSize, cfg.AuditFileMaxBackups)
+		if err != nil {
+			panic(err)
+		}
+
+		auditlog.RegisterSink(sink)
+	}
+
+	verifyLicense(mongo.NewStore(client.Database("main")))
+
+	routes.TokenCookieDomain = cfg.DeviceProxyDomain
+
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			store := mongo.NewStore(client.Database("main"))
@@ -55,6 +98,8 @@ func main() {
 		}
 	})
 
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+
 	publicAPI := e.Group("/api")
 	internalAPI := e.Group("/internal")
 
@@ -78,13 +123,21 @@ func main() {
 		middlewares.Authorize(apicontext.Handler(routes.GetDevice)))
 	publicAPI.DELETE(routes.DeleteDeviceURL, apicontext.Handler(routes.DeleteDevice))
 	publicAPI.PATCH(routes.RenameDeviceURL, apicontext.Handler(routes.RenameDevice))
+	internalAPI.POST(routes.OnlineDeviceURL, apicontext.Handler(routes.OnlineDevice))
 	internalAPI.POST(routes.OfflineDeviceURL, apicontext.Handler(routes.OfflineDevice))
 	internalAPI.GET(routes.LookupDeviceURL, apicontext.Handler(routes.LookupDevice))
+	internalAPI.POST(routes.AuthMQTTClientURL, apicontext.Handler(routes.AuthMQTTClient))
+	internalAPI.GET(routes.AuthMQTTTopicURL, apicontext.Handler(routes.AuthMQTTTopic))
+	internalAPI.POST(routes.MQTTWebhookURL, apicontext.Handler(routes.MQTTWebhook))
 	publicAPI.PATCH(routes.UpdateStatusURL, apicontext.Handler(routes.UpdatePendingStatus))
+	publicAPI.PUT(routes.SetDeviceUpdatePolicyURL, apicontext.Handler(routes.SetDeviceUpdatePolicy))
 	publicAPI.GET(routes.GetSessionsURL,
 		middlewares.Authorize(apicontext.Handler(routes.GetSessionList)))
 	publicAPI.GET(routes.GetSessionURL,
 		middlewares.Authorize(apicontext.Handler(routes.GetSession)))
+	publicAPI.DELETE(routes.TerminateSessionURL,
+		middlewares.Authorize(apicontext.Handler(routes.TerminateSession)))
+	internalAPI.GET(routes.ShadowSessionURL, apicontext.Handler(routes.ShadowSession))
 	internalAPI.PATCH(routes.SetSessionAuthenticatedURL, apicontext.Handler(routes.SetSessionAuthenticated))
 	internalAPI.POST(routes.CreateSessionURL, apicontext.Handler(routes.CreateSession))
 	internalAPI.POST(routes.FinishSessionURL, apicontext.Handler(routes.FinishSession))
@@ -108,6 +161,63 @@ func main() {
 	publicAPI.PUT(routes.EditNamespaceURL, apicontext.Handler(routes.EditNamespace))
 	publicAPI.PATCH(routes.AddNamespaceUserURL, apicontext.Handler(routes.AddNamespaceUser))
 	publicAPI.PATCH(routes.RemoveNamespaceUserURL, apicontext.Handler(routes.RemoveNamespaceUser))
+	publicAPI.PUT(routes.SetNamespaceUpdatePolicyURL, apicontext.Handler(routes.SetNamespaceUpdatePolicy))
+	publicAPI.GET(routes.GetNamespaceUsageURL, apicontext.Handler(routes.GetNamespaceUsage))
+	internalAPI.PUT(routes.SetMaxDevicesURL, apicontext.Handler(routes.SetMaxDevices))
+	publicAPI.PUT(routes.SetShadowWriteURL, apicontext.Handler(routes.SetShadowWrite))
+
+	publicAPI.GET(routes.ListJobsURL,
+		middlewares.Authorize(apicontext.Handler(routes.ListJobs)))
+	publicAPI.POST(routes.CreateJobURL,
+		middlewares.Authorize(apicontext.Handler(routes.CreateJob)))
+	publicAPI.GET(routes.GetJobURL,
+		middlewares.Authorize(apicontext.Handler(routes.GetJob)))
+	publicAPI.GET(routes.ListJobResultsURL,
+		middlewares.Authorize(apicontext.Handler(routes.ListJobResults)))
+	internalAPI.GET(routes.GetJobURL, apicontext.Handler(routes.GetJob))
+	internalAPI.PUT(routes.SetJobResultURL, apicontext.Handler(routes.SetJobResult))
+	internalAPI.POST(routes.StartJobResultURL, apicontext.Handler(routes.StartJobResult))
+	internalAPI.GET(routes.ListWaitingJobResultsURL, apicontext.Handler(routes.ListWaitingJobResults))
+
+	internalAPI.GET(routes.ListTunnelsURL, apicontext.Handler(routes.ListTunnels))
+	internalAPI.GET(routes.GetTunnelURL, apicontext.Handler(routes.GetTunnel))
+	internalAPI.PUT(routes.RegisterTunnelURL, apicontext.Handler(routes.RegisterTunnel))
+	internalAPI.DELETE(routes.UnregisterTunnelURL, apicontext.Handler(routes.UnregisterTunnel))
+
+	internalAPI.GET(routes.ListUpdateChannelsURL, apicontext.Handler(routes.ListUpdateChannels))
+	internalAPI.PUT(routes.SetUpdateChannelURL, apicontext.Handler(routes.SetUpdateChannel))
+	internalAPI.DELETE(routes.DeleteUpdateChannelURL, apicontext.Handler(routes.DeleteUpdateChannel))
+
+	publicAPI.GET(routes.GetAuditEventsURL,
+		middlewares.Authorize(apicontext.Handler(routes.GetAuditEventList)))
+
+	internalAPI.GET(routes.GetLicenseURL, apicontext.Handler(routes.GetLicense))
+	internalAPI.POST(routes.SetLicenseURL, apicontext.Handler(routes.SetLicense))
+	publicAPI.GET(routes.GetFeaturesURL, apicontext.Handler(routes.GetFeatures))
+	internalAPI.GET(routes.GetFeaturesURL, apicontext.Handler(routes.GetFeatures))
 
 	e.Logger.Fatal(e.Start(":8080"))
 }
+
+// verifyLicense checks the license stored, if any, running without the
+// features it enables when it is not valid.
+func verifyLicense(s store.Store) {
+	license, err := licensemngr.NewService(s).GetLicense(context.TODO())
+	switch {
+	case err == store.ErrLicenseNotFound:
+		logrus.Info("No license found, running without licensed features")
+	case err != nil:
+		logrus.WithFields(logrus.Fields{"err": err}).Warn("Failed to verify license")
+	case license.Expired(time.Now()):
+		logrus.WithFields(logrus.Fields{
+			"id":         license.ID,
+			"expires_at": license.ExpiresAt,
+		}).Warn("License expired, running without licensed features")
+	default:
+		logrus.WithFields(logrus.Fields{
+			"id":       license.ID,
+			"customer": license.Customer,
+			"features": license.Features,
+		}).Info("License verified")
+	}
+}
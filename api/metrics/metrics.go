@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "shellhub",
+		Subsystem: "api",
+		Name:      "request_duration_seconds",
+		Help:      "Time spent handling HTTP requests, by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	storeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "shellhub",
+		Subsystem: "api",
+		Name:      "store_operation_duration_seconds",
+		Help:      "Time spent on database commands, by command and collection.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"command", "collection", "status"})
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration, storeDuration)
+}
+
+// Handler returns the handler that exposes the metrics to Prometheus.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware observes the latency of every request labeled by the route
+// pattern, so requests to /devices/:uid for any device are grouped together.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+			if err != nil {
+				// Let echo write the error response so the status is known
+				c.Error(err)
+			}
+
+			requestDuration.WithLabelValues(
+				c.Request().Method,
+				c.Path(),
+				strconv.Itoa(c.Response().Status),
+			).Observe(time.Since(start).Seconds())
+
+			return nil
+		}
+	}
+}
+
+// CommandMonitor returns a mongo command monitor that observes the latency
+// of every command sent to the database.
+func CommandMonitor() *event.CommandMonitor {
+	var started sync.Map
+
+	type command struct {
+		name       string
+		collection string
+	}
+
+	finished := func(requestID int64, status string, duration time.Duration) {
+		value, ok := started.Load(requestID)
+		if !ok {
+			return
+		}
+
+		started.Delete(requestID)
+
+		cmd := value.(command)
+		storeDuration.WithLabelValues(cmd.name, cmd.collection, status).Observe(duration.Seconds())
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
+			collection, _ := evt.Command.Lookup(evt.CommandName).StringValueOK()
+			started.Store(evt.RequestID, command{name: evt.CommandName, collection: collection})
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			finished(evt.RequestID, "success", time.Duration(evt.DurationNanos))
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			finished(evt.RequestID, "failure", time.Duration(evt.DurationNanos))
+		},
+	}
+}
@@ -5,10 +5,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
-	"os"
 	"strings"
 
 	uuid "github.com/satori/go.uuid"
+	"github.com/shellhub-io/shellhub/api/auditlog"
+	"github.com/shellhub-io/shellhub/api/licensemngr"
 	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
@@ -21,6 +22,7 @@ var ErrUserNotFound = errors.New("user not found")
 var ErrNamespaceNotFound = errors.New("namespace not found")
 var ErrDuplicateID = errors.New("user already member of this namespace")
 var ErrUserOwner = errors.New("cannot remove this user")
+var ErrInvalidMaxDevices = errors.New("invalid maximum number of devices")
 
 type Service interface {
 	ListNamespaces(ctx context.Context, pagination paginator.Query, filterB64 string, export bool) ([]models.Namespace, int, error)
@@ -33,6 +35,9 @@ type Service interface {
 	ListMembers(ctx context.Context, namespace string) ([]models.Member, error)
 	UpdateDataUserSecurity(ctx context.Context, status bool, tenant string) error
 	GetDataUserSecurity(ctx context.Context, tenant string) (bool, error)
+	GetUsage(ctx context.Context, tenant string) (*models.NamespaceUsage, error)
+	SetMaxDevices(ctx context.Context, tenant string, max int) error
+	SetShadowWrite(ctx context.Context, tenant, ownerUsername string, allow bool) error
 }
 
 type service struct {
@@ -46,12 +51,12 @@ func NewService(store store.Store) Service {
 func (s *service) ListNamespaces(ctx context.Context, pagination paginator.Query, filterB64 string, export bool) ([]models.Namespace, int, error) {
 	raw, err := base64.StdEncoding.DecodeString(filterB64)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, store.ErrInvalidFilter
 	}
 	var filter []models.Filter
 
 	if err := json.Unmarshal([]byte(raw), &filter); len(raw) > 0 && err != nil {
-		return nil, 0, err
+		return nil, 0, store.ErrInvalidFilter
 	}
 
 	return s.store.ListNamespaces(ctx, pagination, filter, export)
@@ -71,13 +76,22 @@ func (s *service) CreateNamespace(ctx context.Context, namespace *models.Namespa
 	if namespace.TenantID == "" {
 		namespace.TenantID = uuid.Must(uuid.NewV4(), nil).String()
 	}
-	if os.Getenv("SHELLHUB_ENTERPRISE") == "true" {
-		namespace.MaxDevices = 3
-	} else {
-		namespace.MaxDevices = -1
+	namespace.MaxDevices = licensemngr.NewService(s.store).NamespaceMaxDevices(ctx)
+
+	ns, err := s.store.CreateNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.store.CreateNamespace(ctx, namespace)
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: namespace.TenantID,
+		Actor:    ownerUsername,
+		Action:   auditlog.ActionNamespaceCreate,
+		Target:   namespace.TenantID,
+		After:    namespace.Name,
+	})
+
+	return ns, nil
 }
 
 func (s *service) GetNamespace(ctx context.Context, namespace string) (*models.Namespace, error) {
@@ -90,7 +104,19 @@ func (s *service) DeleteNamespace(ctx context.Context, namespace, ownerUsername
 		user, _ := s.store.GetUserByUsername(ctx, ownerUsername)
 		if user != nil {
 			if ns.Owner == user.ID {
-				return s.store.DeleteNamespace(ctx, namespace)
+				if err := s.store.DeleteNamespace(ctx, namespace); err != nil {
+					return err
+				}
+
+				auditlog.Record(ctx, s.store, models.AuditEvent{
+					TenantID: namespace,
+					Actor:    ownerUsername,
+					Action:   auditlog.ActionNamespaceDelete,
+					Target:   namespace,
+					Before:   ns.Name,
+				})
+
+				return nil
 			}
 		}
 		return ErrUnauthorized
@@ -121,9 +147,24 @@ func (s *service) EditNamespace(ctx context.Context, namespace, name, ownerUsern
 			validate := validator.New()
 			name = strings.ToLower(name)
 			if ns.Name != name && ns.Owner == user.ID {
+				oldName := ns.Name
 				ns.Name = name
 				if err := validate.Struct(ns); err == nil {
-					return s.store.EditNamespace(ctx, namespace, name)
+					ns, err := s.store.EditNamespace(ctx, namespace, name)
+					if err != nil {
+						return nil, err
+					}
+
+					auditlog.Record(ctx, s.store, models.AuditEvent{
+						TenantID: namespace,
+						Actor:    ownerUsername,
+						Action:   auditlog.ActionNamespaceRename,
+						Target:   namespace,
+						Before:   oldName,
+						After:    name,
+					})
+
+					return ns, nil
 				}
 			}
 		}
@@ -138,7 +179,19 @@ func (s *service) AddNamespaceUser(ctx context.Context, namespace, username, own
 		if OwnerUser, _ := s.store.GetUserByUsername(ctx, ownerUsername); OwnerUser != nil {
 			if ns.Owner == OwnerUser.ID {
 				if user, _ := s.store.GetUserByUsername(ctx, username); user != nil {
-					return s.store.AddNamespaceUser(ctx, namespace, user.ID)
+					ns, err := s.store.AddNamespaceUser(ctx, namespace, user.ID)
+					if err != nil {
+						return nil, err
+					}
+
+					auditlog.Record(ctx, s.store, models.AuditEvent{
+						TenantID: namespace,
+						Actor:    ownerUsername,
+						Action:   auditlog.ActionNamespaceMemberAdd,
+						Target:   username,
+					})
+
+					return ns, nil
 				}
 				return nil, ErrUserNotFound
 			}
@@ -154,6 +207,13 @@ func (s *service) RemoveNamespaceUser(ctx context.Context, namespace, username,
 			if ns.Owner == OwnerUser.ID {
 				if user, _ := s.store.GetUserByUsername(ctx, username); user != nil {
 					if ns, err := s.store.RemoveNamespaceUser(ctx, namespace, user.ID); err == nil {
+						auditlog.Record(ctx, s.store, models.AuditEvent{
+							TenantID: namespace,
+							Actor:    ownerUsername,
+							Action:   auditlog.ActionNamespaceMemberRemove,
+							Target:   username,
+						})
+
 						return ns, err
 					}
 				}
@@ -168,7 +228,24 @@ func (s *service) RemoveNamespaceUser(ctx context.Context, namespace, username,
 func (s *service) UpdateDataUserSecurity(ctx context.Context, sessionRecord bool, tenant string) error {
 	ns, _ := s.GetNamespace(ctx, tenant)
 	if ns != nil {
-		return s.store.UpdateDataUserSecurity(ctx, sessionRecord, tenant)
+		if err := s.store.UpdateDataUserSecurity(ctx, sessionRecord, tenant); err != nil {
+			return err
+		}
+
+		var before interface{}
+		if ns.Settings != nil {
+			before = ns.Settings.SessionRecord
+		}
+
+		auditlog.Record(ctx, s.store, models.AuditEvent{
+			TenantID: tenant,
+			Action:   auditlog.ActionNamespaceSettings,
+			Target:   "session_record",
+			Before:   before,
+			After:    sessionRecord,
+		})
+
+		return nil
 	}
 	return ErrUnauthorized
 }
@@ -180,3 +257,74 @@ func (s *service) GetDataUserSecurity(ctx context.Context, tenant string) (bool,
 	}
 	return false, ErrUnauthorized
 }
+
+func (s *service) GetUsage(ctx context.Context, tenant string) (*models.NamespaceUsage, error) {
+	usage, err := s.store.GetNamespaceUsage(ctx, tenant)
+	if err == store.ErrNamespaceNoDocuments {
+		return nil, ErrNamespaceNotFound
+	}
+
+	return usage, err
+}
+
+// SetMaxDevices changes the maximum number of accepted devices of the
+// namespace, -1 meaning there is no limit. Devices already accepted above the
+// new limit are kept.
+func (s *service) SetMaxDevices(ctx context.Context, tenant string, max int) error {
+	if max < -1 {
+		return ErrInvalidMaxDevices
+	}
+
+	ns, err := s.store.GetNamespace(ctx, tenant)
+	if err != nil {
+		return ErrNamespaceNotFound
+	}
+
+	if err := s.store.SetNamespaceMaxDevices(ctx, tenant, max); err != nil {
+		return err
+	}
+
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: tenant,
+		Action:   auditlog.ActionNamespaceSettings,
+		Target:   "max_devices",
+		Before:   ns.MaxDevices,
+		After:    max,
+	})
+
+	return nil
+}
+
+// SetShadowWrite allows or forbids the owner of the namespace to type into
+// the sessions being shadowed, which are read-only otherwise.
+func (s *service) SetShadowWrite(ctx context.Context, tenant, ownerUsername string, allow bool) error {
+	ns, err := s.store.GetNamespace(ctx, tenant)
+	if err != nil {
+		return ErrNamespaceNotFound
+	}
+
+	user, _ := s.store.GetUserByUsername(ctx, ownerUsername)
+	if user == nil || ns.Owner != user.ID {
+		return ErrUnauthorized
+	}
+
+	if err := s.store.SetNamespaceShadowWrite(ctx, tenant, allow); err != nil {
+		return err
+	}
+
+	before := false
+	if ns.Settings != nil {
+		before = ns.Settings.ShadowWrite
+	}
+
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: tenant,
+		Actor:    ownerUsername,
+		Action:   auditlog.ActionNamespaceSettings,
+		Target:   "shadow_write",
+		Before:   before,
+		After:    allow,
+	})
+
+	return nil
+}
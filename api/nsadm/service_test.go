@@ -9,6 +9,7 @@ import (
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 )
 
 func TestListNamespaces(t *testing.T) {
@@ -58,7 +59,9 @@ func TestCreateNamespace(t *testing.T) {
 	namespace := &models.Namespace{Name: "group1", Owner: "hash1"}
 
 	mock.On("GetUserByUsername", ctx, user.Username).Return(user, nil).Once()
+	mock.On("LoadLicense", ctx).Return(nil, store.ErrLicenseNotFound).Once()
 	mock.On("CreateNamespace", ctx, namespace).Return(namespace, nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	returnedNamespace, err := s.CreateNamespace(ctx, namespace, namespace.Owner)
 	assert.NoError(t, err)
@@ -81,6 +84,7 @@ func TestEditNamespace(t *testing.T) {
 	mock.On("GetUserByUsername", ctx, user.Username).Return(user, nil).Once()
 	mock.On("GetNamespace", ctx, namespace.TenantID).Return(namespace, nil).Twice()
 	mock.On("EditNamespace", ctx, namespace.TenantID, newName).Return(namespaceWithNewName, nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 	_, err := s.EditNamespace(ctx, namespace.TenantID, newName, namespace.Owner)
 
 	assert.NoError(t, err)
@@ -101,6 +105,7 @@ func TestDeleteNamespace(t *testing.T) {
 
 	mock.On("GetUserByUsername", ctx, user.Username).Return(user, nil).Once()
 	mock.On("DeleteNamespace", ctx, namespace.TenantID).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 	mock.On("GetNamespace", ctx, namespace.TenantID).Return(namespace, nil).Once()
 
 	err := s.DeleteNamespace(ctx, namespace.TenantID, namespace.Owner)
@@ -122,6 +127,7 @@ func TestAddNamespaceUser(t *testing.T) {
 	mock.On("GetUserByUsername", ctx, user.Username).Return(user, nil).Once()
 	mock.On("GetUserByUsername", ctx, member.Username).Return(member, nil).Once()
 	mock.On("AddNamespaceUser", ctx, namespace.TenantID, member.ID).Return(namespace2, nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	_, err := s.AddNamespaceUser(ctx, namespace.TenantID, member.Username, user.Username)
 	assert.NoError(t, err)
@@ -146,6 +152,7 @@ func TestRemoveNamespaceUser(t *testing.T) {
 	mock.On("GetUserByUsername", ctx, user.Username).Return(user, nil).Once()
 	mock.On("GetUserByUsername", ctx, member.Username).Return(member, nil).Once()
 	mock.On("RemoveNamespaceUser", ctx, namespace.TenantID, member.ID).Return(namespace2, nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	_, err := s.RemoveNamespaceUser(ctx, namespace.TenantID, member.Username, user.Username)
 	assert.NoError(t, err)
@@ -189,6 +196,7 @@ func TestUpdateDataUserSecurity(t *testing.T) {
 	mock.On("GetNamespace", ctx, namespace.TenantID).Return(namespace2, nil).Once()
 	mock.On("UpdateDataUserSecurity", ctx, !namespace.Settings.SessionRecord, namespace.TenantID).
 		Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 	mock.On("GetDataUserSecurity", ctx, namespace.TenantID).
 		Return(!namespace.Settings.SessionRecord, nil).Once()
 
@@ -201,3 +209,49 @@ func TestUpdateDataUserSecurity(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func TestSetMaxDevices(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	namespace := &models.Namespace{Name: "group1", Owner: "hash1", TenantID: "a736a52b-5777-4f92-b0b8-e359bf484713", MaxDevices: 3}
+
+	mock.On("GetNamespace", ctx, namespace.TenantID).Return(namespace, nil).Once()
+	mock.On("SetNamespaceMaxDevices", ctx, namespace.TenantID, 10).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+
+	err := s.SetMaxDevices(ctx, namespace.TenantID, -2)
+	assert.Equal(t, ErrInvalidMaxDevices, err)
+
+	err = s.SetMaxDevices(ctx, namespace.TenantID, 10)
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestSetShadowWrite(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	owner := &models.User{Name: "user1", Username: "username1", ID: "hash1"}
+	member := &models.User{Name: "user2", Username: "username2", ID: "hash2"}
+	namespace := &models.Namespace{Name: "group1", Owner: "hash1", TenantID: "a736a52b-5777-4f92-b0b8-e359bf484713"}
+
+	mock.On("GetNamespace", ctx, namespace.TenantID).Return(namespace, nil).Twice()
+	mock.On("GetUserByUsername", ctx, member.Username).Return(member, nil).Once()
+	mock.On("GetUserByUsername", ctx, owner.Username).Return(owner, nil).Once()
+	mock.On("SetNamespaceShadowWrite", ctx, namespace.TenantID, true).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+
+	err := s.SetShadowWrite(ctx, namespace.TenantID, member.Username, true)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	err = s.SetShadowWrite(ctx, namespace.TenantID, owner.Username, true)
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/auditlog"
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/pkg/api/paginator"
+)
+
+const (
+	GetAuditEventsURL = "/audit"
+)
+
+func GetAuditEventList(c apicontext.Context) error {
+	svc := auditlog.NewService(c.Store())
+
+	query := filterQuery{Query: *paginator.NewQuery()}
+	if err := c.Bind(&query); err != nil {
+		return err
+	}
+
+	query.Normalize()
+
+	events, count, err := svc.ListEvents(c.Ctx(), query.Query, query.Filter)
+	if err != nil {
+		if err == store.ErrInvalidFilter {
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		return err
+	}
+
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(count))
+
+	return c.JSON(http.StatusOK, events)
+}
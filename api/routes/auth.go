@@ -2,6 +2,7 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
@@ -23,6 +24,15 @@ const (
 	AuthPublicKeyURL = "/auth/ssh"
 )
 
+// TokenCookie is the cookie holding the token of the user. Browsers send it
+// when opening the HTTP services of the devices proxied by the gateway, as
+// they cannot set the Authorization header on navigation.
+const TokenCookie = "token"
+
+// TokenCookieDomain is the domain of the token cookie. It must cover the
+// hosts the devices are proxied at to authenticate the requests to them.
+var TokenCookieDomain string
+
 func AuthRequest(c apicontext.Context) error {
 	token := c.Get("user").(*jwt.Token)
 	rawClaims := token.Claims.(*jwt.MapClaims)
@@ -88,6 +98,8 @@ func AuthUser(c apicontext.Context) error {
 		return echo.ErrUnauthorized
 	}
 
+	setTokenCookie(c, res.Token)
+
 	return c.JSON(http.StatusOK, res)
 }
 
@@ -141,9 +153,24 @@ func AuthSwapToken(c apicontext.Context) error {
 	if err != nil {
 		return echo.ErrUnauthorized
 	}
+
+	setTokenCookie(c, res.Token)
+
 	return c.JSON(http.StatusOK, res)
 }
 
+func setTokenCookie(c apicontext.Context, token string) {
+	c.SetCookie(&http.Cookie{
+		Name:     TokenCookie,
+		Value:    token,
+		Path:     "/",
+		Domain:   TokenCookieDomain,
+		MaxAge:   int((72 * time.Hour).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
 func AuthPublicKey(c apicontext.Context) error {
 	var req models.PublicKeyAuthRequest
 
@@ -3,9 +3,11 @@ package routes
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/shellhub-io/shellhub/api/apicontext"
 	"github.com/shellhub-io/shellhub/api/deviceadm"
+	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
 )
@@ -15,12 +17,19 @@ const (
 	GetDeviceURL     = "/devices/:uid"
 	DeleteDeviceURL  = "/devices/:uid"
 	RenameDeviceURL  = "/devices/:uid"
+	OnlineDeviceURL  = "/devices/:uid/online"
 	OfflineDeviceURL = "/devices/:uid/offline"
 	LookupDeviceURL  = "/lookup"
 	UpdateStatusURL  = "/devices/:uid/:status"
 )
 
-const TenantIDHeader = "X-Tenant-ID"
+const (
+	TenantIDHeader = "X-Tenant-ID"
+
+	// NextCursorHeader carries the cursor to fetch the page that follows the
+	// current one when the list is sorted by its stable key.
+	NextCursorHeader = "X-Next-Cursor"
+)
 
 type filterQuery struct {
 	Filter string `query:"filter"`
@@ -42,11 +51,23 @@ func GetDeviceList(c apicontext.Context) error {
 
 	devices, count, err := svc.ListDevices(c.Ctx(), query.Query, query.Filter, query.Status, query.SortBy, query.OrderBy)
 	if err != nil {
+		if err == paginator.ErrInvalidCursor || err == store.ErrInvalidFilter {
+			return c.NoContent(http.StatusBadRequest)
+		}
+
 		return err
 	}
 
 	c.Response().Header().Set("X-Total-Count", strconv.Itoa(count))
 
+	// Devices are only listed in the cursor order (newest first) when
+	// explicitly sorted by creation time or already paginating by cursor
+	sorted := query.Cursor != "" || (query.SortBy == "created_at" && query.OrderBy == "desc")
+	if sorted && len(devices) > 0 && len(devices) == query.PerPage {
+		last := devices[len(devices)-1]
+		c.Response().Header().Set(NextCursorHeader, paginator.NewCursor(last.CreatedAt, last.UID))
+	}
+
 	return c.JSON(http.StatusOK, devices)
 }
 
@@ -117,10 +138,30 @@ func RenameDevice(c apicontext.Context) error {
 	return nil
 }
 
+func OnlineDevice(c apicontext.Context) error {
+	return setDevicePresence(c, true)
+}
+
 func OfflineDevice(c apicontext.Context) error {
+	return setDevicePresence(c, false)
+}
+
+func setDevicePresence(c apicontext.Context, online bool) error {
+	var req struct {
+		Time time.Time `json:"time"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if req.Time.IsZero() {
+		req.Time = time.Now()
+	}
+
 	svc := deviceadm.NewService(c.Store())
 
-	if err := svc.UpdateDeviceStatus(c.Ctx(), models.UID(c.Param("uid")), false); err != nil {
+	if err := svc.SetDevicePresence(c.Ctx(), models.UID(c.Param("uid")), online, req.Time); err != nil {
 		return err
 	}
 
@@ -174,6 +215,10 @@ func UpdatePendingStatus(c apicontext.Context) error {
 			return c.NoContent(http.StatusForbidden)
 		}
 
+		if err == deviceadm.ErrMaxDevicesReached {
+			return c.String(http.StatusPaymentRequired, err.Error())
+		}
+
 		return err
 	}
 	return c.JSON(http.StatusOK, nil)
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/store/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDeviceListInvalidFilter(t *testing.T) {
+	mock := &mocks.Store{}
+
+	req := httptest.NewRequest(http.MethodGet, GetDeviceListURL+"?filter=not-base64", nil)
+	rec := httptest.NewRecorder()
+	c := apicontext.NewContext(mock, echo.New().NewContext(req, rec))
+
+	assert.NoError(t, GetDeviceList(*c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	mock.AssertExpectations(t)
+}
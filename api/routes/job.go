@@ -0,0 +1,190 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/jobmngr"
+	"github.com/shellhub-io/shellhub/pkg/api/paginator"
+	"github.com/shellhub-io/shellhub/pkg/models"
+)
+
+const (
+	ListJobsURL              = "/jobs"
+	CreateJobURL             = "/jobs"
+	GetJobURL                = "/jobs/:uid"
+	ListJobResultsURL        = "/jobs/:uid/results"
+	SetJobResultURL          = "/jobs/:uid/results/:device"
+	StartJobResultURL        = "/jobs/:uid/results/:device/start"
+	ListWaitingJobResultsURL = "/devices/:uid/jobs/waiting"
+)
+
+func ListJobs(c apicontext.Context) error {
+	svc := jobmngr.NewService(c.Store())
+
+	query := paginator.NewQuery()
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	query.Normalize()
+
+	jobs, count, err := svc.ListJobs(c.Ctx(), *query)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(count))
+
+	return c.JSON(http.StatusOK, jobs)
+}
+
+func CreateJob(c apicontext.Context) error {
+	var req struct {
+		Command string `json:"command"`
+		User    string `json:"user"`
+		Filter  string `json:"filter"`
+		Retry   bool   `json:"retry"`
+		Timeout int    `json:"timeout"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	tenant := ""
+	if v := c.Tenant(); v != nil {
+		tenant = v.ID
+	}
+
+	username := ""
+	if v := c.Username(); v != nil {
+		username = v.ID
+	}
+
+	svc := jobmngr.NewService(c.Store())
+
+	job, err := svc.CreateJob(c.Ctx(), &models.Job{
+		Command: req.Command,
+		User:    req.User,
+		Filter:  req.Filter,
+		Retry:   req.Retry,
+		Timeout: req.Timeout,
+	}, tenant, username)
+	if err != nil {
+		switch err {
+		case jobmngr.ErrUnauthorized:
+			return c.NoContent(http.StatusForbidden)
+		case jobmngr.ErrInvalidJob:
+			return c.String(http.StatusBadRequest, err.Error())
+		case jobmngr.ErrNoDevices:
+			return c.String(http.StatusUnprocessableEntity, err.Error())
+		default:
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+func GetJob(c apicontext.Context) error {
+	svc := jobmngr.NewService(c.Store())
+
+	job, err := svc.GetJob(c.Ctx(), c.Param("uid"))
+	if err != nil {
+		if err == jobmngr.ErrJobNotFound {
+			return c.NoContent(http.StatusNotFound)
+		}
+
+		return err
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+func ListJobResults(c apicontext.Context) error {
+	svc := jobmngr.NewService(c.Store())
+
+	query := filterQuery{Query: *paginator.NewQuery()}
+	if err := c.Bind(&query); err != nil {
+		return err
+	}
+
+	query.Normalize()
+
+	results, count, err := svc.ListResults(c.Ctx(), c.Param("uid"), query.Status, query.Query)
+	if err != nil {
+		if err == jobmngr.ErrJobNotFound {
+			return c.NoContent(http.StatusNotFound)
+		}
+
+		return err
+	}
+
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(count))
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func SetJobResult(c apicontext.Context) error {
+	var result models.JobResult
+
+	if err := c.Bind(&result); err != nil {
+		return err
+	}
+
+	result.JobUID = c.Param("uid")
+	result.DeviceUID = c.Param("device")
+
+	svc := jobmngr.NewService(c.Store())
+
+	if err := svc.SetResult(c.Ctx(), &result); err != nil {
+		switch err {
+		case jobmngr.ErrInvalidResult:
+			return c.String(http.StatusBadRequest, err.Error())
+		case jobmngr.ErrJobNotFound:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return err
+		}
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func StartJobResult(c apicontext.Context) error {
+	var req struct {
+		Status string `json:"status"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	svc := jobmngr.NewService(c.Store())
+
+	if err := svc.StartResult(c.Ctx(), c.Param("uid"), c.Param("device"), req.Status); err != nil {
+		switch err {
+		case jobmngr.ErrInvalidResult:
+			return c.String(http.StatusBadRequest, err.Error())
+		case jobmngr.ErrResultStarted:
+			return c.NoContent(http.StatusConflict)
+		default:
+			return err
+		}
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func ListWaitingJobResults(c apicontext.Context) error {
+	svc := jobmngr.NewService(c.Store())
+
+	results, err := svc.ListWaitingResults(c.Ctx(), c.Param("uid"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/store/mocks"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+)
+
+func TestCreateJobNotOwner(t *testing.T) {
+	mock := &mocks.Store{}
+
+	namespace := &models.Namespace{TenantID: "tenant", Owner: "owner"}
+	member := &models.User{ID: "member", Username: "member"}
+
+	mock.On("GetNamespace", testifymock.Anything, "tenant").Return(namespace, nil)
+	mock.On("GetUserByUsername", testifymock.Anything, "member").Return(member, nil)
+
+	req := httptest.NewRequest(http.MethodPost, CreateJobURL, strings.NewReader(`{"command": "uptime", "user": "root"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Tenant-ID", "tenant")
+	req.Header.Set("X-Username", "member")
+
+	rec := httptest.NewRecorder()
+	c := apicontext.NewContext(mock, echo.New().NewContext(req, rec))
+
+	assert.NoError(t, CreateJob(*c))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	mock.AssertExpectations(t)
+}
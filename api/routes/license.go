@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/licensemngr"
+	"github.com/shellhub-io/shellhub/api/store"
+)
+
+const (
+	GetLicenseURL  = "/license"
+	SetLicenseURL  = "/license"
+	GetFeaturesURL = "/features"
+)
+
+// maxLicenseSize bounds the size of the license uploaded.
+const maxLicenseSize = 64 * 1024
+
+func GetLicense(c apicontext.Context) error {
+	svc := licensemngr.NewService(c.Store())
+
+	license, err := svc.GetLicense(c.Ctx())
+	if err != nil {
+		switch err {
+		case store.ErrLicenseNotFound:
+			return c.NoContent(http.StatusNotFound)
+		case licensemngr.ErrInvalidLicense:
+			return c.String(http.StatusUnprocessableEntity, err.Error())
+		}
+
+		return err
+	}
+
+	return c.JSON(http.StatusOK, license)
+}
+
+func SetLicense(c apicontext.Context) error {
+	data, err := ioutil.ReadAll(io.LimitReader(c.Request().Body, maxLicenseSize))
+	if err != nil {
+		return err
+	}
+
+	svc := licensemngr.NewService(c.Store())
+
+	license, err := svc.SetLicense(c.Ctx(), data)
+	if err != nil {
+		if err == licensemngr.ErrInvalidLicense || err == licensemngr.ErrLicenseExpired {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+
+		return err
+	}
+
+	return c.JSON(http.StatusOK, license)
+}
+
+func GetFeatures(c apicontext.Context) error {
+	svc := licensemngr.NewService(c.Store())
+
+	features, err := svc.Features(c.Ctx())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, features)
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/authsvc"
+	"github.com/shellhub-io/shellhub/api/deviceadm"
+	"github.com/shellhub-io/shellhub/pkg/models"
+)
+
+// Endpoints called by the HTTP authentication and web hook plugins of the
+// MQTT broker.
+const (
+	AuthMQTTClientURL = "/mqtt/auth"
+	AuthMQTTTopicURL  = "/mqtt/acl"
+	MQTTWebhookURL    = "/mqtt/webhook"
+)
+
+func AuthMQTTClient(c apicontext.Context) error {
+	var query models.MqttAuthQuery
+	if err := c.Bind(&query); err != nil {
+		return err
+	}
+
+	svc := authsvc.NewService(c.Store(), nil, nil)
+
+	if err := svc.AuthMQTTClient(c.Ctx(), &query); err != nil {
+		return echo.ErrUnauthorized
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func AuthMQTTTopic(c apicontext.Context) error {
+	var query models.MqttACLQuery
+	if err := c.Bind(&query); err != nil {
+		return err
+	}
+
+	svc := authsvc.NewService(c.Store(), nil, nil)
+
+	if err := svc.AuthMQTTTopic(c.Ctx(), &query); err != nil {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func MQTTWebhook(c apicontext.Context) error {
+	var evt models.MqttEvent
+	if err := c.Bind(&evt); err != nil {
+		return err
+	}
+
+	svc := deviceadm.NewService(c.Store())
+
+	var online bool
+
+	switch evt.Action {
+	case models.MqttClientConnectedEventType:
+		online = true
+	case models.MqttClientDisconnectedEventType:
+		online = false
+	default:
+		// Other events are not of interest
+		return c.NoContent(http.StatusOK)
+	}
+
+	if err := svc.SetMQTTPresence(c.Ctx(), models.UID(evt.Username), online, time.Now()); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
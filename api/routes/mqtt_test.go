@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindMQTTAuthBody(t *testing.T) {
+	form := url.Values{"username": {"uid"}, "password": {"token"}, "ipaddr": {"127.0.0.1"}}
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"form", echo.MIMEApplicationForm, form.Encode()},
+		{"json", echo.MIMEApplicationJSON, `{"username": "uid", "password": "token", "ipaddr": "127.0.0.1"}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, AuthMQTTClientURL, strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, tc.contentType)
+
+			var query models.MqttAuthQuery
+			assert.NoError(t, echo.New().NewContext(req, httptest.NewRecorder()).Bind(&query))
+			assert.Equal(t, models.MqttAuthQuery{Username: "uid", Password: "token", IPAddr: "127.0.0.1"}, query)
+		})
+	}
+}
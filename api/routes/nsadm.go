@@ -6,6 +6,7 @@ import (
 
 	"github.com/shellhub-io/shellhub/api/apicontext"
 	"github.com/shellhub-io/shellhub/api/nsadm"
+	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/pkg/models"
 )
 
@@ -17,6 +18,9 @@ const (
 	EditNamespaceURL       = "/namespace/:id"
 	AddNamespaceUserURL    = "/namespace/:id/add"
 	RemoveNamespaceUserURL = "/namespace/:id/del"
+	GetNamespaceUsageURL   = "/namespace/:id/usage"
+	SetMaxDevicesURL       = "/namespace/:id/max-devices"
+	SetShadowWriteURL      = "/namespace/:id/shadow-write"
 	UserSecurityURL        = "/users/security"
 	UpdateUserSecurityURL  = "/users/security/:id"
 )
@@ -31,6 +35,10 @@ func GetNamespaceList(c apicontext.Context) error {
 
 	namespaces, count, err := svc.ListNamespaces(c.Ctx(), query.Query, query.Filter, false)
 	if err != nil {
+		if err == store.ErrInvalidFilter {
+			return c.NoContent(http.StatusBadRequest)
+		}
+
 		return err
 	}
 
@@ -244,3 +252,91 @@ func GetUserSecurity(c apicontext.Context) error {
 
 	return c.JSON(http.StatusOK, status)
 }
+
+func GetNamespaceUsage(c apicontext.Context) error {
+	tenant := ""
+	if v := c.Tenant(); v != nil {
+		tenant = v.ID
+	}
+
+	if tenant != c.Param("id") {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	svc := nsadm.NewService(c.Store())
+
+	usage, err := svc.GetUsage(c.Ctx(), tenant)
+	if err != nil {
+		if err == nsadm.ErrNamespaceNotFound {
+			return c.String(http.StatusNotFound, err.Error())
+		}
+
+		return err
+	}
+
+	return c.JSON(http.StatusOK, usage)
+}
+
+func SetMaxDevices(c apicontext.Context) error {
+	var req struct {
+		MaxDevices *int `json:"max_devices"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if req.MaxDevices == nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	svc := nsadm.NewService(c.Store())
+
+	if err := svc.SetMaxDevices(c.Ctx(), c.Param("id"), *req.MaxDevices); err != nil {
+		if err == nsadm.ErrInvalidMaxDevices {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+
+		if err == nsadm.ErrNamespaceNotFound {
+			return c.String(http.StatusNotFound, err.Error())
+		}
+
+		return err
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func SetShadowWrite(c apicontext.Context) error {
+	var req struct {
+		ShadowWrite *bool `json:"shadow_write"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if req.ShadowWrite == nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	username := ""
+	if v := c.Username(); v != nil {
+		username = v.ID
+	}
+
+	svc := nsadm.NewService(c.Store())
+
+	if err := svc.SetShadowWrite(c.Ctx(), c.Param("id"), username, *req.ShadowWrite); err != nil {
+		switch err {
+		case nsadm.ErrUnauthorized:
+			return c.NoContent(http.StatusForbidden)
+		case nsadm.ErrNamespaceNotFound:
+			return c.String(http.StatusNotFound, err.Error())
+		default:
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
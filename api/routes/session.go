@@ -6,6 +6,7 @@ import (
 
 	"github.com/shellhub-io/shellhub/api/apicontext"
 	"github.com/shellhub-io/shellhub/api/sessionmngr"
+	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
 )
@@ -14,6 +15,8 @@ const (
 	GetSessionsURL             = "/sessions"
 	GetSessionURL              = "/sessions/:uid"
 	SetSessionAuthenticatedURL = "/sessions/:uid"
+	TerminateSessionURL        = "/sessions/:uid"
+	ShadowSessionURL           = "/sessions/:uid/shadow"
 	CreateSessionURL           = "/sessions"
 	FinishSessionURL           = "/sessions/:uid/finish"
 	RecordSessionURL           = "/sessions/:uid/record"
@@ -23,21 +26,33 @@ const (
 func GetSessionList(c apicontext.Context) error {
 	svc := sessionmngr.NewService(c.Store())
 
-	query := paginator.NewQuery()
-	if err := c.Bind(query); err != nil {
+	query := filterQuery{Query: *paginator.NewQuery()}
+	if err := c.Bind(&query); err != nil {
 		return err
 	}
 
 	// TODO: normalize is not required when request is privileged
 	query.Normalize()
 
-	sessions, count, err := svc.ListSessions(c.Ctx(), *query)
+	sessions, count, err := svc.ListSessions(c.Ctx(), query.Query, query.Filter, query.SortBy, query.OrderBy)
 	if err != nil {
+		if err == paginator.ErrInvalidCursor || err == store.ErrInvalidFilter {
+			return c.NoContent(http.StatusBadRequest)
+		}
+
 		return err
 	}
 
 	c.Response().Header().Set("X-Total-Count", strconv.Itoa(count))
 
+	// Sessions are only listed in the cursor order (newest first) by default,
+	// when explicitly sorted by start time or already paginating by cursor
+	sorted := query.Cursor != "" || query.SortBy == "" || (query.SortBy == "started_at" && query.OrderBy == "desc")
+	if sorted && len(sessions) > 0 && len(sessions) == query.PerPage {
+		last := sessions[len(sessions)-1]
+		c.Response().Header().Set(NextCursorHeader, paginator.NewCursor(last.StartedAt, last.UID))
+	}
+
 	return c.JSON(http.StatusOK, sessions)
 }
 
@@ -89,6 +104,54 @@ func FinishSession(c apicontext.Context) error {
 	return svc.DeactivateSession(c.Ctx(), models.UID(c.Param("uid")))
 }
 
+func TerminateSession(c apicontext.Context) error {
+	svc := sessionmngr.NewService(c.Store())
+
+	tenant := ""
+	if v := c.Tenant(); v != nil {
+		tenant = v.ID
+	}
+
+	username := ""
+	if v := c.Username(); v != nil {
+		username = v.ID
+	}
+
+	if err := svc.TerminateSession(c.Ctx(), models.UID(c.Param("uid")), tenant, username); err != nil {
+		switch err {
+		case sessionmngr.ErrUnauthorized:
+			return c.NoContent(http.StatusForbidden)
+		case sessionmngr.ErrSessionNotActive:
+			return c.NoContent(http.StatusConflict)
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ShadowSession authorizes the gateway to attach a user to an active session.
+func ShadowSession(c apicontext.Context) error {
+	svc := sessionmngr.NewService(c.Store())
+
+	write := c.QueryParam("write") == "true"
+
+	session, err := svc.AuthorizeShadow(c.Ctx(), models.UID(c.Param("uid")), c.QueryParam("username"), write)
+	if err != nil {
+		switch err {
+		case sessionmngr.ErrUnauthorized:
+			return c.NoContent(http.StatusForbidden)
+		case sessionmngr.ErrSessionNotActive:
+			return c.NoContent(http.StatusConflict)
+		default:
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
 func RecordSession(c apicontext.Context) error {
 	return c.JSON(http.StatusOK, nil)
 }
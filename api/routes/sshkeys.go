@@ -34,11 +34,20 @@ func GetPublicKeys(c apicontext.Context) error {
 
 	list, count, err := svc.ListPublicKeys(c.Ctx(), *query)
 	if err != nil {
+		if err == paginator.ErrInvalidCursor {
+			return c.NoContent(http.StatusBadRequest)
+		}
+
 		return err
 	}
 
 	c.Response().Header().Set("X-Total-Count", strconv.Itoa(count))
 
+	if len(list) > 0 && len(list) == query.PerPage {
+		last := list[len(list)-1]
+		c.Response().Header().Set(NextCursorHeader, paginator.NewCursor(last.CreatedAt, last.Fingerprint))
+	}
+
 	return c.JSON(http.StatusOK, list)
 }
 
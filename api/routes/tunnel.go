@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/api/tunnelmngr"
+)
+
+const (
+	ListTunnelsURL      = "/tunnels"
+	GetTunnelURL        = "/tunnels/:uid"
+	RegisterTunnelURL   = "/tunnels/:uid"
+	UnregisterTunnelURL = "/tunnels/:uid"
+)
+
+func ListTunnels(c apicontext.Context) error {
+	svc := tunnelmngr.NewService(c.Store())
+
+	tunnels, err := svc.ListTunnels(c.Ctx(), c.QueryParam("instance"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tunnels)
+}
+
+func GetTunnel(c apicontext.Context) error {
+	svc := tunnelmngr.NewService(c.Store())
+
+	tunnel, err := svc.GetTunnel(c.Ctx(), c.Param("uid"))
+	if err != nil {
+		if err == store.ErrTunnelNotFound {
+			return c.NoContent(http.StatusNotFound)
+		}
+
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tunnel)
+}
+
+func RegisterTunnel(c apicontext.Context) error {
+	var req struct {
+		Instance string `json:"instance"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if req.Instance == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	svc := tunnelmngr.NewService(c.Store())
+
+	return svc.RegisterTunnel(c.Ctx(), c.Param("uid"), req.Instance)
+}
+
+func UnregisterTunnel(c apicontext.Context) error {
+	svc := tunnelmngr.NewService(c.Store())
+
+	if err := svc.UnregisterTunnel(c.Ctx(), c.Param("uid"), c.QueryParam("instance")); err != nil {
+		if err == store.ErrTunnelNotFound {
+			return c.NoContent(http.StatusNotFound)
+		}
+
+		return err
+	}
+
+	return nil
+}
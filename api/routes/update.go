@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/shellhub-io/shellhub/api/apicontext"
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/api/updatemngr"
+	"github.com/shellhub-io/shellhub/pkg/models"
+)
+
+const (
+	ListUpdateChannelsURL       = "/update-channels"
+	SetUpdateChannelURL         = "/update-channels/:name"
+	DeleteUpdateChannelURL      = "/update-channels/:name"
+	SetNamespaceUpdatePolicyURL = "/namespace/:id/update-policy"
+	SetDeviceUpdatePolicyURL    = "/devices/:uid/update-policy"
+)
+
+func ListUpdateChannels(c apicontext.Context) error {
+	svc := updatemngr.NewService(c.Store())
+
+	channels, err := svc.ListChannels(c.Ctx())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, channels)
+}
+
+func SetUpdateChannel(c apicontext.Context) error {
+	var req struct {
+		Version         string `json:"version"`
+		PreviousVersion string `json:"previous_version"`
+		Rollout         *int   `json:"rollout"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	channel := &models.UpdateChannel{
+		Name:            c.Param("name"),
+		Version:         req.Version,
+		PreviousVersion: req.PreviousVersion,
+		Rollout:         100,
+	}
+
+	if req.Rollout != nil {
+		channel.Rollout = *req.Rollout
+	}
+
+	svc := updatemngr.NewService(c.Store())
+
+	if err := svc.SetChannel(c.Ctx(), channel); err != nil {
+		if err == updatemngr.ErrInvalidChannel {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+
+		return err
+	}
+
+	return c.JSON(http.StatusOK, channel)
+}
+
+func DeleteUpdateChannel(c apicontext.Context) error {
+	svc := updatemngr.NewService(c.Store())
+
+	if err := svc.DeleteChannel(c.Ctx(), c.Param("name")); err != nil {
+		if err == store.ErrUpdateChannelNotFound {
+			return c.NoContent(http.StatusNotFound)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// bindUpdatePolicy binds the policy of the request, an empty one meaning the
+// policy is removed.
+func bindUpdatePolicy(c apicontext.Context) (*models.UpdatePolicy, error) {
+	var req models.UpdatePolicy
+	if err := c.Bind(&req); err != nil {
+		return nil, err
+	}
+
+	if req.Channel == "" && req.Version == "" {
+		return nil, nil
+	}
+
+	return &req, nil
+}
+
+func updatePolicyError(c apicontext.Context, err error) error {
+	switch err {
+	case updatemngr.ErrInvalidChannel, updatemngr.ErrInvalidPolicy:
+		return c.String(http.StatusBadRequest, err.Error())
+	case updatemngr.ErrDeviceNotFound, updatemngr.ErrNamespaceNotFound:
+		return c.String(http.StatusNotFound, err.Error())
+	}
+
+	return err
+}
+
+func SetNamespaceUpdatePolicy(c apicontext.Context) error {
+	tenant := ""
+	if v := c.Tenant(); v != nil {
+		tenant = v.ID
+	}
+
+	if tenant != c.Param("id") {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	policy, err := bindUpdatePolicy(c)
+	if err != nil {
+		return err
+	}
+
+	svc := updatemngr.NewService(c.Store())
+
+	if err := svc.SetNamespacePolicy(c.Ctx(), tenant, policy); err != nil {
+		return updatePolicyError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, policy)
+}
+
+func SetDeviceUpdatePolicy(c apicontext.Context) error {
+	tenant := ""
+	if v := c.Tenant(); v != nil {
+		tenant = v.ID
+	}
+
+	policy, err := bindUpdatePolicy(c)
+	if err != nil {
+		return err
+	}
+
+	svc := updatemngr.NewService(c.Store())
+
+	if err := svc.SetDevicePolicy(c.Ctx(), models.UID(c.Param("uid")), tenant, policy); err != nil {
+		return updatePolicyError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, policy)
+}
@@ -1,19 +1,38 @@
 package sessionmngr
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
 
+	"github.com/shellhub-io/shellhub/api/auditlog"
 	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
 )
 
+// GatewayAddress is the address of the SSH gateway, which closes the
+// sessions through the tunnel of their devices.
+var GatewayAddress = "ssh:8080"
+
+var (
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrSessionNotActive = errors.New("session not active")
+)
+
 type Service interface {
-	ListSessions(ctx context.Context, pagination paginator.Query) ([]models.Session, int, error)
+	ListSessions(ctx context.Context, pagination paginator.Query, filter string, sort string, order string) ([]models.Session, int, error)
 	GetSession(ctx context.Context, uid models.UID) (*models.Session, error)
 	CreateSession(ctx context.Context, session models.Session) (*models.Session, error)
 	DeactivateSession(ctx context.Context, uid models.UID) error
 	SetSessionAuthenticated(ctx context.Context, uid models.UID, authenticated bool) error
+	TerminateSession(ctx context.Context, uid models.UID, tenant, username string) error
+	AuthorizeShadow(ctx context.Context, uid models.UID, username string, write bool) (*models.Session, error)
 }
 
 type service struct {
@@ -24,8 +43,19 @@ func NewService(store store.Store) Service {
 	return &service{store}
 }
 
-func (s *service) ListSessions(ctx context.Context, pagination paginator.Query) ([]models.Session, int, error) {
-	return s.store.ListSessions(ctx, pagination)
+func (s *service) ListSessions(ctx context.Context, pagination paginator.Query, filterB64 string, sort string, order string) ([]models.Session, int, error) {
+	raw, err := base64.StdEncoding.DecodeString(filterB64)
+	if err != nil {
+		return nil, 0, store.ErrInvalidFilter
+	}
+
+	var filter []models.Filter
+
+	if err := json.Unmarshal([]byte(raw), &filter); len(raw) > 0 && err != nil {
+		return nil, 0, store.ErrInvalidFilter
+	}
+
+	return s.store.ListSessions(ctx, pagination, filter, sort, order)
 }
 
 func (s *service) GetSession(ctx context.Context, uid models.UID) (*models.Session, error) {
@@ -33,13 +63,170 @@ func (s *service) GetSession(ctx context.Context, uid models.UID) (*models.Sessi
 }
 
 func (s *service) CreateSession(ctx context.Context, session models.Session) (*models.Session, error) {
-	return s.store.CreateSession(ctx, session)
+	created, err := s.store.CreateSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	auditlog.Emit(ctx, models.AuditEvent{
+		TenantID: created.TenantID,
+		Actor:    created.Username,
+		Action:   auditlog.ActionSessionCreate,
+		Target:   created.UID,
+		After: map[string]string{
+			"device_uid": string(created.DeviceUID),
+			"ip_address": created.IPAddress,
+		},
+	})
+
+	return created, nil
 }
 
 func (s *service) DeactivateSession(ctx context.Context, uid models.UID) error {
-	return s.store.DeactivateSession(ctx, uid)
+	if err := s.store.DeactivateSession(ctx, uid); err != nil {
+		return err
+	}
+
+	event := models.AuditEvent{
+		Action: auditlog.ActionSessionFinish,
+		Target: string(uid),
+	}
+
+	if session, _ := s.store.GetSession(ctx, uid); session != nil {
+		event.TenantID = session.TenantID
+		event.Actor = session.Username
+	}
+
+	auditlog.Emit(ctx, event)
+
+	return nil
 }
 
 func (s *service) SetSessionAuthenticated(ctx context.Context, uid models.UID, authenticated bool) error {
 	return s.store.SetSessionAuthenticated(ctx, uid, authenticated)
 }
+
+// TerminateSession asks the gateway to close an active session and marks it
+// as finished, recording who terminated it.
+func (s *service) TerminateSession(ctx context.Context, uid models.UID, tenant, username string) error {
+	session, _ := s.store.GetSession(ctx, uid)
+	if session == nil || session.TenantID != tenant {
+		return ErrUnauthorized
+	}
+
+	if !session.Active {
+		return ErrSessionNotActive
+	}
+
+	if err := closeSession(ctx, session); err != nil {
+		return err
+	}
+
+	reason := fmt.Sprintf("terminated by %s", username)
+
+	if err := s.store.SetSessionReason(ctx, uid, reason); err != nil {
+		return err
+	}
+
+	// Close the recording with the reason so it is shown when replayed
+	if session.Recorded {
+		if err := s.recordReason(ctx, uid, reason); err != nil {
+			return err
+		}
+	}
+
+	if err := s.store.DeactivateSession(ctx, uid); err != nil {
+		return err
+	}
+
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: tenant,
+		Actor:    username,
+		Action:   auditlog.ActionSessionTerminate,
+		Target:   string(uid),
+		After:    reason,
+	})
+
+	return nil
+}
+
+// AuthorizeShadow lets the owner of the namespace of an active session watch
+// it, and type into it when write is set and the namespace allows it.
+func (s *service) AuthorizeShadow(ctx context.Context, uid models.UID, username string, write bool) (*models.Session, error) {
+	session, _ := s.store.GetSession(ctx, uid)
+	if session == nil {
+		return nil, ErrUnauthorized
+	}
+
+	ns, _ := s.store.GetNamespace(ctx, session.TenantID)
+	user, _ := s.store.GetUserByUsername(ctx, username)
+	if ns == nil || user == nil || ns.Owner != user.ID {
+		return nil, ErrUnauthorized
+	}
+
+	if write && (ns.Settings == nil || !ns.Settings.ShadowWrite) {
+		return nil, ErrUnauthorized
+	}
+
+	if !session.Active {
+		return nil, ErrSessionNotActive
+	}
+
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: session.TenantID,
+		Actor:    username,
+		Action:   auditlog.ActionSessionShadow,
+		Target:   string(uid),
+		After: map[string]interface{}{
+			"username": session.Username,
+			"write":    write,
+		},
+	})
+
+	return session, nil
+}
+
+// recordReason appends the reason the session finished to its recording,
+// keeping the size of the terminal of the last frame.
+func (s *service) recordReason(ctx context.Context, uid models.UID, reason string) error {
+	records, _, err := s.store.GetRecord(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	var width, height int
+	if len(records) > 0 {
+		width, height = records[len(records)-1].Width, records[len(records)-1].Height
+	}
+
+	return s.store.RecordSession(ctx, uid, fmt.Sprintf("\r\n[session %s]\r\n", reason), width, height)
+}
+
+func closeSession(ctx context.Context, session *models.Session) error {
+	body, err := json.Marshal(map[string]string{"device": string(session.DeviceUID)})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/sessions/%s/close", GatewayAddress, session.UID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to close session: %s", res.Status)
+	}
+
+	return nil
+}
@@ -2,6 +2,11 @@ package sessionmngr
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/shellhub-io/shellhub/api/store"
@@ -9,6 +14,7 @@ import (
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 )
 
 func TestListSessions(t *testing.T) {
@@ -21,12 +27,23 @@ func TestListSessions(t *testing.T) {
 		{UID: "uid"},
 	}
 
+	filters := []models.Filter{
+		{
+			Type:   "property",
+			Params: &models.PropertyParams{Name: "username", Operator: "eq", Value: "root"}},
+	}
+
+	filterJSON, err := json.Marshal(filters)
+	assert.NoError(t, err)
+
+	encodedFilter := base64.StdEncoding.EncodeToString(filterJSON)
+
 	query := paginator.Query{Page: 1, PerPage: 10}
 
-	mock.On("ListSessions", ctx, query).
+	mock.On("ListSessions", ctx, query, filters, "started_at", "desc").
 		Return(sessions, len(sessions), nil).Once()
 
-	returnedSessions, count, err := s.ListSessions(ctx, query)
+	returnedSessions, count, err := s.ListSessions(ctx, query, encodedFilter, "started_at", "desc")
 	assert.NoError(t, err)
 	assert.Equal(t, sessions, returnedSessions)
 	assert.Equal(t, count, len(sessions))
@@ -76,6 +93,8 @@ func TestDeactivateSession(t *testing.T) {
 
 	mock.On("DeactivateSession", ctx, models.UID("uid")).
 		Return(nil).Once()
+	mock.On("GetSession", ctx, models.UID("uid")).
+		Return(&models.Session{UID: "uid"}, nil).Once()
 
 	err := s.DeactivateSession(ctx, models.UID("uid"))
 	assert.NoError(t, err)
@@ -97,3 +116,94 @@ func TestSetSessionAuthenticated(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func TestTerminateSession(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	var closed string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Device string `json:"device"`
+		}
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "device", req.Device)
+
+		closed = r.URL.Path
+	}))
+	defer gateway.Close()
+
+	GatewayAddress = strings.TrimPrefix(gateway.URL, "http://")
+
+	session := &models.Session{UID: "uid", DeviceUID: "device", TenantID: "tenant", Active: true}
+
+	mock.On("GetSession", ctx, models.UID("uid")).Return(session, nil).Once()
+
+	err := s.TerminateSession(ctx, models.UID("uid"), "other", "user")
+	assert.Equal(t, ErrUnauthorized, err)
+
+	mock.On("GetSession", ctx, models.UID("uid")).Return(&models.Session{UID: "uid", TenantID: "tenant"}, nil).Once()
+
+	err = s.TerminateSession(ctx, models.UID("uid"), "tenant", "user")
+	assert.Equal(t, ErrSessionNotActive, err)
+	assert.Empty(t, closed)
+
+	mock.On("GetSession", ctx, models.UID("uid")).Return(session, nil).Once()
+	mock.On("SetSessionReason", ctx, models.UID("uid"), "terminated by user").Return(nil).Once()
+	mock.On("DeactivateSession", ctx, models.UID("uid")).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+
+	err = s.TerminateSession(ctx, models.UID("uid"), "tenant", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "/sessions/uid/close", closed)
+
+	// The reason is also appended to the recording of recorded sessions
+	recorded := &models.Session{UID: "uid", DeviceUID: "device", TenantID: "tenant", Active: true, Recorded: true}
+	records := []models.RecordedSession{{UID: "uid", Message: "$ ", Width: 80, Height: 24}}
+
+	mock.On("GetSession", ctx, models.UID("uid")).Return(recorded, nil).Once()
+	mock.On("SetSessionReason", ctx, models.UID("uid"), "terminated by user").Return(nil).Once()
+	mock.On("GetRecord", ctx, models.UID("uid")).Return(records, len(records), nil).Once()
+	mock.On("RecordSession", ctx, models.UID("uid"), "\r\n[session terminated by user]\r\n", 80, 24).Return(nil).Once()
+	mock.On("DeactivateSession", ctx, models.UID("uid")).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+
+	err = s.TerminateSession(ctx, models.UID("uid"), "tenant", "user")
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestAuthorizeShadow(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	owner := &models.User{Username: "owner", ID: "owner-id"}
+	member := &models.User{Username: "member", ID: "member-id"}
+	namespace := &models.Namespace{TenantID: "tenant", Owner: owner.ID}
+	session := &models.Session{UID: "uid", TenantID: "tenant", Username: "root", Active: true}
+
+	mock.On("GetSession", ctx, models.UID("uid")).Return(session, nil).Times(3)
+	mock.On("GetNamespace", ctx, "tenant").Return(namespace, nil).Times(3)
+	mock.On("GetUserByUsername", ctx, member.Username).Return(member, nil).Once()
+	mock.On("GetUserByUsername", ctx, owner.Username).Return(owner, nil).Twice()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+
+	_, err := s.AuthorizeShadow(ctx, models.UID("uid"), member.Username, false)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Typing into the session is not allowed by the namespace
+	_, err = s.AuthorizeShadow(ctx, models.UID("uid"), owner.Username, true)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	returned, err := s.AuthorizeShadow(ctx, models.UID("uid"), owner.Username, false)
+	assert.NoError(t, err)
+	assert.Equal(t, session, returned)
+
+	mock.AssertExpectations(t)
+}
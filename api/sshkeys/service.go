@@ -9,6 +9,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/shellhub-io/shellhub/api/auditlog"
 	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
@@ -53,7 +54,18 @@ func (s *service) CreatePublicKey(ctx context.Context, key *models.PublicKey) er
 	if err == store.ErrDuplicateFingerprint {
 		return ErrDuplicateFingerprint
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: key.TenantID,
+		Action:   auditlog.ActionPublicKeyCreate,
+		Target:   key.Fingerprint,
+		After:    key.PublicKeyFields,
+	})
+
+	return nil
 }
 
 func (s *service) ListPublicKeys(ctx context.Context, pagination paginator.Query) ([]models.PublicKey, int, error) {
@@ -61,11 +73,45 @@ func (s *service) ListPublicKeys(ctx context.Context, pagination paginator.Query
 }
 
 func (s *service) UpdatePublicKey(ctx context.Context, fingerprint, tenant string, key *models.PublicKeyUpdate) (*models.PublicKey, error) {
-	return s.store.UpdatePublicKey(ctx, fingerprint, tenant, key)
+	event := models.AuditEvent{
+		TenantID: tenant,
+		Action:   auditlog.ActionPublicKeyUpdate,
+		Target:   fingerprint,
+		After:    key.PublicKeyFields,
+	}
+
+	if old, _ := s.store.GetPublicKey(ctx, fingerprint, tenant); old != nil {
+		event.Before = old.PublicKeyFields
+	}
+
+	newKey, err := s.store.UpdatePublicKey(ctx, fingerprint, tenant, key)
+	if err != nil {
+		return nil, err
+	}
+
+	auditlog.Record(ctx, s.store, event)
+
+	return newKey, nil
 }
 
 func (s *service) DeletePublicKey(ctx context.Context, fingerprint, tenant string) error {
-	return s.store.DeletePublicKey(ctx, fingerprint, tenant)
+	event := models.AuditEvent{
+		TenantID: tenant,
+		Action:   auditlog.ActionPublicKeyDelete,
+		Target:   fingerprint,
+	}
+
+	if old, _ := s.store.GetPublicKey(ctx, fingerprint, tenant); old != nil {
+		event.Before = old.PublicKeyFields
+	}
+
+	if err := s.store.DeletePublicKey(ctx, fingerprint, tenant); err != nil {
+		return err
+	}
+
+	auditlog.Record(ctx, s.store, event)
+
+	return nil
 }
 
 func (s *service) CreatePrivateKey(ctx context.Context) (*models.PrivateKey, error) {
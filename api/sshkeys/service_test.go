@@ -10,6 +10,7 @@ import (
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 )
 
 func TestListPublicKeys(t *testing.T) {
@@ -68,7 +69,9 @@ func TestUpdatePublicKeys(t *testing.T) {
 		Data: []byte("teste"), Fingerprint: "fingerprint", CreatedAt: time.Now(), TenantID: "tenant1", PublicKeyFields: models.PublicKeyFields{Name: "teste2"},
 	}
 
+	mock.On("GetPublicKey", ctx, key.Fingerprint, key.TenantID).Return(key, nil).Once()
 	mock.On("UpdatePublicKey", ctx, key.Fingerprint, key.TenantID, keyUpdate).Return(newKey, nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	returnedKey, err := s.UpdatePublicKey(ctx, key.Fingerprint, key.TenantID, keyUpdate)
 	assert.NoError(t, err)
@@ -86,7 +89,9 @@ func TestDeletePublicKeys(t *testing.T) {
 		Data: []byte("teste"), Fingerprint: "fingerprint", CreatedAt: time.Now(), TenantID: "tenant1", PublicKeyFields: models.PublicKeyFields{Name: "teste"},
 	}
 
+	mock.On("GetPublicKey", ctx, key.Fingerprint, key.TenantID).Return(key, nil).Once()
 	mock.On("DeletePublicKey", ctx, key.Fingerprint, key.TenantID).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	err := s.DeletePublicKey(ctx, key.Fingerprint, key.TenantID)
 	assert.NoError(t, err)
@@ -105,6 +110,7 @@ func TestCreatePublicKey(t *testing.T) {
 	}
 
 	mock.On("CreatePublicKey", ctx, key).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	err := s.CreatePublicKey(ctx, key)
 	assert.NoError(t, err)
@@ -9,6 +9,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	paginator "github.com/shellhub-io/shellhub/pkg/api/paginator"
+
+	time "time"
 )
 
 // Store is an autogenerated mock type for the Store type
@@ -53,6 +55,20 @@ func (_m *Store) AddNamespaceUser(ctx context.Context, namespace string, ID stri
 	return r0, r1
 }
 
+// CreateAuditEvent provides a mock function with given fields: ctx, event
+func (_m *Store) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	ret := _m.Called(ctx, event)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AuditEvent) error); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateFirewallRule provides a mock function with given fields: ctx, rule
 func (_m *Store) CreateFirewallRule(ctx context.Context, rule *models.FirewallRule) error {
 	ret := _m.Called(ctx, rule)
@@ -67,6 +83,20 @@ func (_m *Store) CreateFirewallRule(ctx context.Context, rule *models.FirewallRu
 	return r0
 }
 
+// CreateJob provides a mock function with given fields: ctx, job, results
+func (_m *Store) CreateJob(ctx context.Context, job *models.Job, results []models.JobResult) error {
+	ret := _m.Called(ctx, job, results)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Job, []models.JobResult) error); ok {
+		r0 = rf(ctx, job, results)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateNamespace provides a mock function with given fields: ctx, namespace
 func (_m *Store) CreateNamespace(ctx context.Context, namespace *models.Namespace) (*models.Namespace, error) {
 	ret := _m.Called(ctx, namespace)
@@ -225,6 +255,34 @@ func (_m *Store) DeletePublicKey(ctx context.Context, fingerprint string, tenant
 	return r0
 }
 
+// DeleteTunnel provides a mock function with given fields: ctx, uid, instance
+func (_m *Store) DeleteTunnel(ctx context.Context, uid string, instance string) error {
+	ret := _m.Called(ctx, uid, instance)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, uid, instance)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUpdateChannel provides a mock function with given fields: ctx, name
+func (_m *Store) DeleteUpdateChannel(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteUser provides a mock function with given fields: ctx, ID
 func (_m *Store) DeleteUser(ctx context.Context, ID string) error {
 	ret := _m.Called(ctx, ID)
@@ -262,6 +320,20 @@ func (_m *Store) EditNamespace(ctx context.Context, namespace string, name strin
 	return r0, r1
 }
 
+// FinishJob provides a mock function with given fields: ctx, uid, at
+func (_m *Store) FinishJob(ctx context.Context, uid string, at time.Time) error {
+	ret := _m.Called(ctx, uid, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, uid, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetDataUserSecurity provides a mock function with given fields: ctx, tenant
 func (_m *Store) GetDataUserSecurity(ctx context.Context, tenant string) (bool, error) {
 	ret := _m.Called(ctx, tenant)
@@ -398,6 +470,29 @@ func (_m *Store) GetFirewallRule(ctx context.Context, id string) (*models.Firewa
 	return r0, r1
 }
 
+// GetJob provides a mock function with given fields: ctx, uid
+func (_m *Store) GetJob(ctx context.Context, uid string) (*models.Job, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 *models.Job
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Job); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Job)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNamespace provides a mock function with given fields: ctx, namespace
 func (_m *Store) GetNamespace(ctx context.Context, namespace string) (*models.Namespace, error) {
 	ret := _m.Called(ctx, namespace)
@@ -444,6 +539,29 @@ func (_m *Store) GetNamespaceByName(ctx context.Context, namespace string) (*mod
 	return r0, r1
 }
 
+// GetNamespaceUsage provides a mock function with given fields: ctx, tenant
+func (_m *Store) GetNamespaceUsage(ctx context.Context, tenant string) (*models.NamespaceUsage, error) {
+	ret := _m.Called(ctx, tenant)
+
+	var r0 *models.NamespaceUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.NamespaceUsage); ok {
+		r0 = rf(ctx, tenant)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.NamespaceUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenant)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetPrivateKey provides a mock function with given fields: ctx, fingerprint
 func (_m *Store) GetPrivateKey(ctx context.Context, fingerprint string) (*models.PrivateKey, error) {
 	ret := _m.Called(ctx, fingerprint)
@@ -589,6 +707,52 @@ func (_m *Store) GetStats(ctx context.Context) (*models.Stats, error) {
 	return r0, r1
 }
 
+// GetTunnel provides a mock function with given fields: ctx, uid
+func (_m *Store) GetTunnel(ctx context.Context, uid string) (*models.Tunnel, error) {
+	ret := _m.Called(ctx, uid)
+
+	var r0 *models.Tunnel
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Tunnel); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Tunnel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUpdateChannel provides a mock function with given fields: ctx, name
+func (_m *Store) GetUpdateChannel(ctx context.Context, name string) (*models.UpdateChannel, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *models.UpdateChannel
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.UpdateChannel); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UpdateChannel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserByEmail provides a mock function with given fields: ctx, email
 func (_m *Store) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	ret := _m.Called(ctx, email)
@@ -695,6 +859,59 @@ func (_m *Store) KeepAliveSession(ctx context.Context, uid models.UID) error {
 	return r0
 }
 
+// ListAuditEvents provides a mock function with given fields: ctx, pagination, filters
+func (_m *Store) ListAuditEvents(ctx context.Context, pagination paginator.Query, filters []models.Filter) ([]models.AuditEvent, int, error) {
+	ret := _m.Called(ctx, pagination, filters)
+
+	var r0 []models.AuditEvent
+	if rf, ok := ret.Get(0).(func(context.Context, paginator.Query, []models.Filter) []models.AuditEvent); ok {
+		r0 = rf(ctx, pagination, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AuditEvent)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, paginator.Query, []models.Filter) int); ok {
+		r1 = rf(ctx, pagination, filters)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, paginator.Query, []models.Filter) error); ok {
+		r2 = rf(ctx, pagination, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListDeviceJobResults provides a mock function with given fields: ctx, device, status
+func (_m *Store) ListDeviceJobResults(ctx context.Context, device string, status string) ([]models.JobResult, error) {
+	ret := _m.Called(ctx, device, status)
+
+	var r0 []models.JobResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []models.JobResult); ok {
+		r0 = rf(ctx, device, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.JobResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, device, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListDevices provides a mock function with given fields: ctx, pagination, filters, status, sort, order
 func (_m *Store) ListDevices(ctx context.Context, pagination paginator.Query, filters []models.Filter, status string, sort string, order string) ([]models.Device, int, error) {
 	ret := _m.Called(ctx, pagination, filters, status, sort, order)
@@ -755,6 +972,66 @@ func (_m *Store) ListFirewallRules(ctx context.Context, pagination paginator.Que
 	return r0, r1, r2
 }
 
+// ListJobResults provides a mock function with given fields: ctx, job, statuses, pagination
+func (_m *Store) ListJobResults(ctx context.Context, job string, statuses []string, pagination paginator.Query) ([]models.JobResult, int, error) {
+	ret := _m.Called(ctx, job, statuses, pagination)
+
+	var r0 []models.JobResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, paginator.Query) []models.JobResult); ok {
+		r0 = rf(ctx, job, statuses, pagination)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.JobResult)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, paginator.Query) int); ok {
+		r1 = rf(ctx, job, statuses, pagination)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, []string, paginator.Query) error); ok {
+		r2 = rf(ctx, job, statuses, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListJobs provides a mock function with given fields: ctx, pagination
+func (_m *Store) ListJobs(ctx context.Context, pagination paginator.Query) ([]models.Job, int, error) {
+	ret := _m.Called(ctx, pagination)
+
+	var r0 []models.Job
+	if rf, ok := ret.Get(0).(func(context.Context, paginator.Query) []models.Job); ok {
+		r0 = rf(ctx, pagination)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Job)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, paginator.Query) int); ok {
+		r1 = rf(ctx, pagination)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, paginator.Query) error); ok {
+		r2 = rf(ctx, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // ListNamespaces provides a mock function with given fields: ctx, pagination, filters, export
 func (_m *Store) ListNamespaces(ctx context.Context, pagination paginator.Query, filters []models.Filter, export bool) ([]models.Namespace, int, error) {
 	ret := _m.Called(ctx, pagination, filters, export)
@@ -815,13 +1092,13 @@ func (_m *Store) ListPublicKeys(ctx context.Context, pagination paginator.Query)
 	return r0, r1, r2
 }
 
-// ListSessions provides a mock function with given fields: ctx, pagination
-func (_m *Store) ListSessions(ctx context.Context, pagination paginator.Query) ([]models.Session, int, error) {
-	ret := _m.Called(ctx, pagination)
+// ListSessions provides a mock function with given fields: ctx, pagination, filters, sort, order
+func (_m *Store) ListSessions(ctx context.Context, pagination paginator.Query, filters []models.Filter, sort string, order string) ([]models.Session, int, error) {
+	ret := _m.Called(ctx, pagination, filters, sort, order)
 
 	var r0 []models.Session
-	if rf, ok := ret.Get(0).(func(context.Context, paginator.Query) []models.Session); ok {
-		r0 = rf(ctx, pagination)
+	if rf, ok := ret.Get(0).(func(context.Context, paginator.Query, []models.Filter, string, string) []models.Session); ok {
+		r0 = rf(ctx, pagination, filters, sort, order)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.Session)
@@ -829,15 +1106,15 @@ func (_m *Store) ListSessions(ctx context.Context, pagination paginator.Query) (
 	}
 
 	var r1 int
-	if rf, ok := ret.Get(1).(func(context.Context, paginator.Query) int); ok {
-		r1 = rf(ctx, pagination)
+	if rf, ok := ret.Get(1).(func(context.Context, paginator.Query, []models.Filter, string, string) int); ok {
+		r1 = rf(ctx, pagination, filters, sort, order)
 	} else {
 		r1 = ret.Get(1).(int)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, paginator.Query) error); ok {
-		r2 = rf(ctx, pagination)
+	if rf, ok := ret.Get(2).(func(context.Context, paginator.Query, []models.Filter, string, string) error); ok {
+		r2 = rf(ctx, pagination, filters, sort, order)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -845,6 +1122,52 @@ func (_m *Store) ListSessions(ctx context.Context, pagination paginator.Query) (
 	return r0, r1, r2
 }
 
+// ListTunnels provides a mock function with given fields: ctx, instance
+func (_m *Store) ListTunnels(ctx context.Context, instance string) ([]models.Tunnel, error) {
+	ret := _m.Called(ctx, instance)
+
+	var r0 []models.Tunnel
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.Tunnel); ok {
+		r0 = rf(ctx, instance)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Tunnel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, instance)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUpdateChannels provides a mock function with given fields: ctx
+func (_m *Store) ListUpdateChannels(ctx context.Context) ([]models.UpdateChannel, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []models.UpdateChannel
+	if rf, ok := ret.Get(0).(func(context.Context) []models.UpdateChannel); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UpdateChannel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListUsers provides a mock function with given fields: ctx, pagination, filters
 func (_m *Store) ListUsers(ctx context.Context, pagination paginator.Query, filters []models.Filter) ([]models.User, int, error) {
 	ret := _m.Called(ctx, pagination, filters)
@@ -986,6 +1309,76 @@ func (_m *Store) SaveLicense(ctx context.Context, license *models.License) error
 	return r0
 }
 
+// SetDevicePresence provides a mock function with given fields: ctx, uid, online, at
+func (_m *Store) SetDevicePresence(ctx context.Context, uid models.UID, online bool, at time.Time) error {
+	ret := _m.Called(ctx, uid, online, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UID, bool, time.Time) error); ok {
+		r0 = rf(ctx, uid, online, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetDeviceUpdatePolicy provides a mock function with given fields: ctx, uid, policy
+func (_m *Store) SetDeviceUpdatePolicy(ctx context.Context, uid models.UID, policy *models.UpdatePolicy) error {
+	ret := _m.Called(ctx, uid, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UID, *models.UpdatePolicy) error); ok {
+		r0 = rf(ctx, uid, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetNamespaceMaxDevices provides a mock function with given fields: ctx, tenant, max
+func (_m *Store) SetNamespaceMaxDevices(ctx context.Context, tenant string, max int) error {
+	ret := _m.Called(ctx, tenant, max)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, tenant, max)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetNamespaceShadowWrite provides a mock function with given fields: ctx, tenant, allow
+func (_m *Store) SetNamespaceShadowWrite(ctx context.Context, tenant string, allow bool) error {
+	ret := _m.Called(ctx, tenant, allow)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, tenant, allow)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetNamespaceUpdatePolicy provides a mock function with given fields: ctx, tenant, policy
+func (_m *Store) SetNamespaceUpdatePolicy(ctx context.Context, tenant string, policy *models.UpdatePolicy) error {
+	ret := _m.Called(ctx, tenant, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *models.UpdatePolicy) error); ok {
+		r0 = rf(ctx, tenant, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetSessionAuthenticated provides a mock function with given fields: ctx, uid, authenticated
 func (_m *Store) SetSessionAuthenticated(ctx context.Context, uid models.UID, authenticated bool) error {
 	ret := _m.Called(ctx, uid, authenticated)
@@ -1000,6 +1393,62 @@ func (_m *Store) SetSessionAuthenticated(ctx context.Context, uid models.UID, au
 	return r0
 }
 
+// SetSessionReason provides a mock function with given fields: ctx, uid, reason
+func (_m *Store) SetSessionReason(ctx context.Context, uid models.UID, reason string) error {
+	ret := _m.Called(ctx, uid, reason)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UID, string) error); ok {
+		r0 = rf(ctx, uid, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetTunnel provides a mock function with given fields: ctx, tunnel
+func (_m *Store) SetTunnel(ctx context.Context, tunnel *models.Tunnel) error {
+	ret := _m.Called(ctx, tunnel)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Tunnel) error); ok {
+		r0 = rf(ctx, tunnel)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUpdateChannel provides a mock function with given fields: ctx, channel
+func (_m *Store) SetUpdateChannel(ctx context.Context, channel *models.UpdateChannel) error {
+	ret := _m.Called(ctx, channel)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.UpdateChannel) error); ok {
+		r0 = rf(ctx, channel)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StartJobResult provides a mock function with given fields: ctx, job, device, status, at
+func (_m *Store) StartJobResult(ctx context.Context, job string, device string, status string, at time.Time) error {
+	ret := _m.Called(ctx, job, device, status, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time) error); ok {
+		r0 = rf(ctx, job, device, status, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateDataUserSecurity provides a mock function with given fields: ctx, sessionRecord, tenant
 func (_m *Store) UpdateDataUserSecurity(ctx context.Context, sessionRecord bool, tenant string) error {
 	ret := _m.Called(ctx, sessionRecord, tenant)
@@ -1051,6 +1500,20 @@ func (_m *Store) UpdateFirewallRule(ctx context.Context, id string, rule models.
 	return r0, r1
 }
 
+// UpdateJobResult provides a mock function with given fields: ctx, result
+func (_m *Store) UpdateJobResult(ctx context.Context, result *models.JobResult) error {
+	ret := _m.Called(ctx, result)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.JobResult) error); ok {
+		r0 = rf(ctx, result)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdatePendingStatus provides a mock function with given fields: ctx, uid, status
 func (_m *Store) UpdatePendingStatus(ctx context.Context, uid models.UID, status string) error {
 	ret := _m.Called(ctx, uid, status)
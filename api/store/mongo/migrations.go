@@ -604,6 +604,159 @@ var migrations = []migrate.Migration{
 			return nil
 		},
 	},
+	{
+		Version: 19,
+		Up: func(db *mongo.Database) error {
+			// Use the creation time embedded in the object id for devices registered before
+			// the created_at field existed
+			if _, err := db.Collection("devices").UpdateMany(context.TODO(), bson.M{"created_at": bson.M{"$exists": false}}, []bson.M{
+				{"$set": bson.M{"created_at": bson.M{"$toDate": "$_id"}}},
+			}); err != nil {
+				return err
+			}
+
+			mod := mongo.IndexModel{
+				Keys:    bson.D{{Key: "created_at", Value: -1}, {Key: "uid", Value: -1}},
+				Options: options.Index().SetName("created_at_uid").SetUnique(false),
+			}
+			if _, err := db.Collection("devices").Indexes().CreateOne(context.TODO(), mod); err != nil {
+				return err
+			}
+
+			mod = mongo.IndexModel{
+				Keys:    bson.D{{Key: "started_at", Value: -1}, {Key: "uid", Value: -1}},
+				Options: options.Index().SetName("started_at_uid").SetUnique(false),
+			}
+			if _, err := db.Collection("sessions").Indexes().CreateOne(context.TODO(), mod); err != nil {
+				return err
+			}
+
+			mod = mongo.IndexModel{
+				Keys:    bson.D{{Key: "created_at", Value: 1}, {Key: "fingerprint", Value: 1}},
+				Options: options.Index().SetName("created_at_fingerprint").SetUnique(false),
+			}
+			_, err := db.Collection("public_keys").Indexes().CreateOne(context.TODO(), mod)
+			return err
+		},
+		Down: func(db *mongo.Database) error {
+			if _, err := db.Collection("devices").Indexes().DropOne(context.TODO(), "created_at_uid"); err != nil {
+				return err
+			}
+			if _, err := db.Collection("sessions").Indexes().DropOne(context.TODO(), "started_at_uid"); err != nil {
+				return err
+			}
+			_, err := db.Collection("public_keys").Indexes().DropOne(context.TODO(), "created_at_fingerprint")
+			return err
+		},
+	},
+	{
+		Version: 20,
+		Up: func(db *mongo.Database) error {
+			mod := mongo.IndexModel{
+				Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "time", Value: -1}},
+				Options: options.Index().SetName("tenant_id_time").SetUnique(false),
+			}
+			_, err := db.Collection("audit_events").Indexes().CreateOne(context.TODO(), mod)
+			return err
+		},
+		Down: func(db *mongo.Database) error {
+			_, err := db.Collection("audit_events").Indexes().DropOne(context.TODO(), "tenant_id_time")
+			return err
+		},
+	},
+	{
+		Version: 21,
+		Up: func(db *mongo.Database) error {
+			mod := mongo.IndexModel{
+				Keys:    bson.D{{Key: "uid", Value: 1}},
+				Options: options.Index().SetName("uid").SetUnique(true),
+			}
+			_, err := db.Collection("tunnels").Indexes().CreateOne(context.TODO(), mod)
+			return err
+		},
+		Down: func(db *mongo.Database) error {
+			_, err := db.Collection("tunnels").Indexes().DropOne(context.TODO(), "uid")
+			return err
+		},
+	},
+	{
+		Version: 22,
+		Up: func(db *mongo.Database) error {
+			mod := mongo.IndexModel{
+				Keys:    bson.D{{Key: "instance", Value: 1}},
+				Options: options.Index().SetName("instance").SetUnique(false),
+			}
+			_, err := db.Collection("tunnels").Indexes().CreateOne(context.TODO(), mod)
+			return err
+		},
+		Down: func(db *mongo.Database) error {
+			_, err := db.Collection("tunnels").Indexes().DropOne(context.TODO(), "instance")
+			return err
+		},
+	},
+	{
+		Version: 23,
+		Up: func(db *mongo.Database) error {
+			mod := mongo.IndexModel{
+				Keys:    bson.D{{Key: "name", Value: 1}},
+				Options: options.Index().SetName("name").SetUnique(true),
+			}
+			_, err := db.Collection("update_channels").Indexes().CreateOne(context.TODO(), mod)
+			return err
+		},
+		Down: func(db *mongo.Database) error {
+			_, err := db.Collection("update_channels").Indexes().DropOne(context.TODO(), "name")
+			return err
+		},
+	},
+	{
+		Version: 24,
+		Up: func(db *mongo.Database) error {
+			// Namespaces created outside of the API have no limit
+			_, err := db.Collection("namespaces").UpdateMany(context.TODO(), bson.M{"max_devices": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"max_devices": -1}})
+			return err
+		},
+		Down: func(db *mongo.Database) error {
+			return nil
+		},
+	},
+	{
+		Version: 25,
+		Up: func(db *mongo.Database) error {
+			mod := mongo.IndexModel{
+				Keys:    bson.D{{Key: "uid", Value: 1}},
+				Options: options.Index().SetName("uid").SetUnique(true),
+			}
+			if _, err := db.Collection("jobs").Indexes().CreateOne(context.TODO(), mod); err != nil {
+				return err
+			}
+
+			mod = mongo.IndexModel{
+				Keys:    bson.D{{Key: "job_uid", Value: 1}, {Key: "device_uid", Value: 1}},
+				Options: options.Index().SetName("job_uid_device_uid").SetUnique(true),
+			}
+			if _, err := db.Collection("job_results").Indexes().CreateOne(context.TODO(), mod); err != nil {
+				return err
+			}
+
+			mod = mongo.IndexModel{
+				Keys:    bson.D{{Key: "device_uid", Value: 1}, {Key: "status", Value: 1}},
+				Options: options.Index().SetName("device_uid_status").SetUnique(false),
+			}
+			_, err := db.Collection("job_results").Indexes().CreateOne(context.TODO(), mod)
+			return err
+		},
+		Down: func(db *mongo.Database) error {
+			if _, err := db.Collection("jobs").Indexes().DropOne(context.TODO(), "uid"); err != nil {
+				return err
+			}
+			if _, err := db.Collection("job_results").Indexes().DropOne(context.TODO(), "job_uid_device_uid"); err != nil {
+				return err
+			}
+			_, err := db.Collection("job_results").Indexes().DropOne(context.TODO(), "device_uid_status")
+			return err
+		},
+	},
 }
 
 func ApplyMigrations(db *mongo.Database) error {
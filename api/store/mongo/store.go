@@ -83,14 +83,18 @@ func (s *Store) ListDevices(ctx context.Context, pagination paginator.Query, fil
 		"desc": -1,
 	}
 
-	if sort != "" {
-		query = append(query, bson.M{
-			"$sort": bson.M{sort: orderVal[order]},
-		})
-	} else {
-		query = append(query, bson.M{
-			"$sort": bson.M{"last_seen": -1},
-		})
+	// Cursor pagination has its own stable sort order, while the pages are
+	// kept stable by the uid when the sorted field is equal
+	if pagination.Cursor == "" {
+		if sort != "" && orderVal[order] != 0 {
+			query = append(query, bson.M{
+				"$sort": bson.D{{Key: sort, Value: orderVal[order]}, {Key: "uid", Value: orderVal[order]}},
+			})
+		} else {
+			query = append(query, bson.M{
+				"$sort": bson.D{{Key: "last_seen", Value: -1}, {Key: "uid", Value: -1}},
+			})
+		}
 	}
 
 	// Apply filters if any
@@ -113,7 +117,17 @@ func (s *Store) ListDevices(ctx context.Context, pagination paginator.Query, fil
 		return nil, 0, err
 	}
 
-	query = append(query, buildPaginationQuery(pagination)...)
+	if pagination.Cursor != "" {
+		cursor, err := paginator.ParseCursor(pagination.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		query = append(query, buildCursorQuery(cursor, "created_at", "uid", -1)...)
+		query = append(query, bson.M{"$limit": pagination.PerPage})
+	} else {
+		query = append(query, buildPaginationQuery(pagination)...)
+	}
 
 	devices := make([]models.Device, 0)
 
@@ -218,8 +232,9 @@ func (s *Store) AddDevice(ctx context.Context, d models.Device, hostname string)
 
 	q := bson.M{
 		"$setOnInsert": bson.M{
-			"name":   hostname,
-			"status": "pending",
+			"name":       hostname,
+			"status":     "pending",
+			"created_at": time.Now(),
 		},
 		"$set": d,
 	}
@@ -278,6 +293,18 @@ func (s *Store) UpdateDeviceStatus(ctx context.Context, uid models.UID, online b
 	return nil
 }
 
+// SetDevicePresence records when the tunnel of a device was connected or
+// disconnected from the gateway.
+func (s *Store) SetDevicePresence(ctx context.Context, uid models.UID, online bool, at time.Time) error {
+	field := "last_disconnected"
+	if online {
+		field = "last_connected"
+	}
+
+	_, err := s.db.Collection("devices").UpdateOne(ctx, bson.M{"uid": uid}, bson.M{"$set": bson.M{field: at}})
+	return err
+}
+
 func (s *Store) UpdatePendingStatus(ctx context.Context, uid models.UID, status string) error {
 	device := new(models.Device)
 	if err := s.db.Collection("devices").FindOne(ctx, bson.M{"uid": uid}).Decode(&device); err != nil {
@@ -302,14 +329,13 @@ func (s *Store) UpdatePendingStatus(ctx context.Context, uid models.UID, status
 	return nil
 }
 
-func (s *Store) ListSessions(ctx context.Context, pagination paginator.Query) ([]models.Session, int, error) {
-	query := []bson.M{
-		{
-			"$sort": bson.M{
-				"started_at": -1,
-			},
-		},
+func (s *Store) ListSessions(ctx context.Context, pagination paginator.Query, filters []models.Filter, sort string, order string) ([]models.Session, int, error) {
+	queryMatch, err := buildFilterQuery(filters)
+	if err != nil {
+		return nil, 0, err
+	}
 
+	query := []bson.M{
 		{
 			"$lookup": bson.M{
 				"from":         "active_sessions",
@@ -325,6 +351,26 @@ func (s *Store) ListSessions(ctx context.Context, pagination paginator.Query) ([
 		},
 	}
 
+	// Only join the device when filtering or sorting by any of its fields
+	if strings.HasPrefix(sort, "device.") || hasFilterWithPrefix(filters, "device.") {
+		query = append(query, []bson.M{
+			{
+				"$lookup": bson.M{
+					"from":         "devices",
+					"localField":   "device_uid",
+					"foreignField": "uid",
+					"as":           "device",
+				},
+			},
+			{
+				"$unwind": bson.M{
+					"path":                       "$device",
+					"preserveNullAndEmptyArrays": true,
+				},
+			},
+		}...)
+	}
+
 	// Only match for the respective tenant if requested
 	if tenant := apicontext.TenantFromContext(ctx); tenant != nil {
 		query = append(query, bson.M{
@@ -334,13 +380,42 @@ func (s *Store) ListSessions(ctx context.Context, pagination paginator.Query) ([
 		})
 	}
 
+	// Apply filters if any
+	if len(queryMatch) > 0 {
+		query = append(query, queryMatch...)
+	}
+
 	queryCount := append(query, bson.M{"$count": "count"})
 	count, err := aggregateCount(ctx, s.db.Collection("sessions"), queryCount)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	query = append(query, buildPaginationQuery(pagination)...)
+	if pagination.Cursor != "" {
+		cursor, err := paginator.ParseCursor(pagination.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Resume before any lookup so the sort can be served by the index
+		query = append(buildCursorQuery(cursor, "started_at", "uid", -1), query...)
+		query = append(query, bson.M{"$limit": pagination.PerPage})
+	} else {
+		orderVal := map[string]int{
+			"asc":  1,
+			"desc": -1,
+		}
+
+		if sort != "" && orderVal[order] != 0 {
+			query = append(query, bson.M{
+				"$sort": bson.D{{Key: sort, Value: orderVal[order]}, {Key: "uid", Value: orderVal[order]}},
+			})
+		} else {
+			query = append([]bson.M{{"$sort": bson.D{{Key: "started_at", Value: -1}, {Key: "uid", Value: -1}}}}, query...)
+		}
+
+		query = append(query, buildPaginationQuery(pagination)...)
+	}
 
 	sessions := make([]models.Session, 0)
 	cursor, err := s.db.Collection("sessions").Aggregate(ctx, query)
@@ -426,6 +501,11 @@ func (s *Store) SetSessionAuthenticated(ctx context.Context, uid models.UID, aut
 	return err
 }
 
+func (s *Store) SetSessionReason(ctx context.Context, uid models.UID, reason string) error {
+	_, err := s.db.Collection("sessions").UpdateOne(ctx, bson.M{"uid": uid}, bson.M{"$set": bson.M{"reason": reason}})
+	return err
+}
+
 func (s *Store) CreateSession(ctx context.Context, session models.Session) (*models.Session, error) {
 	session.StartedAt = time.Now()
 	session.LastSeen = session.StartedAt
@@ -694,16 +774,20 @@ func (s *Store) GetUserByID(ctx context.Context, ID string) (*models.User, error
 }
 
 func (s *Store) GetDeviceByMac(ctx context.Context, mac, tenant, status string) (*models.Device, error) {
-	device := new(models.Device)
+	query := bson.M{"tenant_id": tenant, "identity": bson.M{"mac": mac}}
 	if status != "" {
-		if err := s.db.Collection("devices").FindOne(ctx, bson.M{"tenant_id": tenant, "identity": bson.M{"mac": mac}, "status": status}).Decode(&device); err != nil {
-			return nil, err
-		}
-	} else {
-		if err := s.db.Collection("devices").FindOne(ctx, bson.M{"tenant_id": tenant, "identity": bson.M{"mac": mac}}).Decode(&device); err != nil {
-			return nil, err
+		query["status"] = status
+	}
+
+	device := new(models.Device)
+	if err := s.db.Collection("devices").FindOne(ctx, query).Decode(&device); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, store.ErrDeviceNotFound
 		}
+
+		return nil, err
 	}
+
 	return device, nil
 }
 
@@ -1012,32 +1096,30 @@ func buildFilterQuery(filters []models.Filter) ([]bson.M, error) {
 				var value bool
 
 				switch v := params.Value.(type) {
+				case bool:
+					value = v
 				case int:
 					value = v != 0
+				case float64:
+					value = v != 0
 				case string:
 					var err error
 					value, err = strconv.ParseBool(v)
 					if err != nil {
-						return nil, err
+						return nil, store.ErrInvalidFilter
 					}
+				default:
+					return nil, store.ErrInvalidFilter
 				}
 
 				property = bson.M{"$eq": value}
-			case "gt":
-				var value int
-
-				switch v := params.Value.(type) {
-				case int:
-					value = v
-				case string:
-					var err error
-					value, err = strconv.Atoi(v)
-					if err != nil {
-						return nil, err
-					}
+			case "gt", "lt":
+				value, err := parseComparableValue(params.Value)
+				if err != nil {
+					return nil, err
 				}
 
-				property = bson.M{"$gt": value}
+				property = bson.M{"$" + params.Operator: value}
 			}
 
 			queryFilter = append(queryFilter, bson.M{
@@ -1074,6 +1156,43 @@ func buildFilterQuery(filters []models.Filter) ([]bson.M, error) {
 	return queryMatch, nil
 }
 
+// parseComparableValue converts the value of a gt or lt filter into something
+// mongo can compare: numbers are kept as is and strings are parsed either as
+// an integer or as a RFC 3339 timestamp.
+func parseComparableValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return v, nil
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i, nil
+		}
+
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, store.ErrInvalidFilter
+		}
+
+		return t, nil
+	}
+
+	return nil, store.ErrInvalidFilter
+}
+
+// hasFilterWithPrefix reports whether any property filter refers to a field
+// whose name starts with prefix.
+func hasFilterWithPrefix(filters []models.Filter, prefix string) bool {
+	for _, filter := range filters {
+		if params, ok := filter.Params.(*models.PropertyParams); ok && strings.HasPrefix(params.Name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (s *Store) ListUsers(ctx context.Context, pagination paginator.Query, filters []models.Filter) ([]models.User, int, error) {
 	query := []bson.M{}
 
@@ -1162,6 +1281,10 @@ func (s *Store) LoadLicense(ctx context.Context) (*models.License, error) {
 
 	license := new(models.License)
 	if err := s.db.Collection("licenses").FindOne(ctx, bson.M{}, findOpts).Decode(&license); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, store.ErrLicenseNotFound
+		}
+
 		return nil, err
 	}
 
@@ -1197,13 +1320,7 @@ func (s *Store) GetPublicKey(ctx context.Context, fingerprint, tenant string) (*
 }
 
 func (s *Store) ListPublicKeys(ctx context.Context, pagination paginator.Query) ([]models.PublicKey, int, error) {
-	query := []bson.M{
-		{
-			"$sort": bson.M{
-				"created_at": 1,
-			},
-		},
-	}
+	query := []bson.M{}
 
 	// Only match for the respective tenant if requested
 	if tenant := apicontext.TenantFromContext(ctx); tenant != nil {
@@ -1220,7 +1337,18 @@ func (s *Store) ListPublicKeys(ctx context.Context, pagination paginator.Query)
 		return nil, 0, err
 	}
 
-	query = append(query, buildPaginationQuery(pagination)...)
+	if pagination.Cursor != "" {
+		cursor, err := paginator.ParseCursor(pagination.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		query = append(query, buildCursorQuery(cursor, "created_at", "fingerprint", 1)...)
+		query = append(query, bson.M{"$limit": pagination.PerPage})
+	} else {
+		query = append(query, bson.M{"$sort": bson.D{{Key: "created_at", Value: 1}, {Key: "fingerprint", Value: 1}}})
+		query = append(query, buildPaginationQuery(pagination)...)
+	}
 
 	list := make([]models.PublicKey, 0)
 	cursor, err := s.db.Collection("public_keys").Aggregate(ctx, query)
@@ -1306,6 +1434,54 @@ func (s *Store) GetNamespace(ctx context.Context, namespace string) (*models.Nam
 	return ns, nil
 }
 
+func (s *Store) GetNamespaceUsage(ctx context.Context, tenant string) (*models.NamespaceUsage, error) {
+	ns, err := s.GetNamespace(ctx, tenant)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, store.ErrNamespaceNoDocuments
+		}
+
+		return nil, err
+	}
+
+	pending, err := s.db.Collection("devices").CountDocuments(ctx, bson.M{"tenant_id": tenant, "status": "pending"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NamespaceUsage{
+		Devices:        ns.DevicesCount,
+		PendingDevices: int(pending),
+		MaxDevices:     ns.MaxDevices,
+	}, nil
+}
+
+func (s *Store) SetNamespaceMaxDevices(ctx context.Context, tenant string, max int) error {
+	res, err := s.db.Collection("namespaces").UpdateOne(ctx, bson.M{"tenant_id": tenant}, bson.M{"$set": bson.M{"max_devices": max}})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return store.ErrNamespaceNoDocuments
+	}
+
+	return nil
+}
+
+func (s *Store) SetNamespaceShadowWrite(ctx context.Context, tenant string, allow bool) error {
+	res, err := s.db.Collection("namespaces").UpdateOne(ctx, bson.M{"tenant_id": tenant}, bson.M{"$set": bson.M{"settings.shadow_write": allow}})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return store.ErrNamespaceNoDocuments
+	}
+
+	return nil
+}
+
 func (s *Store) GetNamespaceByName(ctx context.Context, namespace string) (*models.Namespace, error) {
 	ns := new(models.Namespace)
 
@@ -1469,6 +1645,434 @@ func (s *Store) GetSomeNamespace(ctx context.Context, ID string) (*models.Namesp
 	return ns, nil
 }
 
+func (s *Store) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	_, err := s.db.Collection("audit_events").InsertOne(ctx, event)
+	return err
+}
+
+func (s *Store) ListAuditEvents(ctx context.Context, pagination paginator.Query, filters []models.Filter) ([]models.AuditEvent, int, error) {
+	queryMatch, err := buildFilterQuery(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := []bson.M{}
+
+	// Only match for the respective tenant if requested
+	if tenant := apicontext.TenantFromContext(ctx); tenant != nil {
+		query = append(query, bson.M{
+			"$match": bson.M{
+				"tenant_id": tenant.ID,
+			},
+		})
+	}
+
+	// Apply filters if any
+	if len(queryMatch) > 0 {
+		query = append(query, queryMatch...)
+	}
+
+	queryCount := append(query, bson.M{"$count": "count"})
+	count, err := aggregateCount(ctx, s.db.Collection("audit_events"), queryCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = append(query, bson.M{
+		"$sort": bson.M{"time": -1},
+	})
+
+	query = append(query, buildPaginationQuery(pagination)...)
+
+	events := make([]models.AuditEvent, 0)
+	cursor, err := s.db.Collection("audit_events").Aggregate(ctx, query)
+	if err != nil {
+		return events, count, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		event := new(models.AuditEvent)
+		if err := cursor.Decode(&event); err != nil {
+			return events, count, err
+		}
+
+		events = append(events, *event)
+	}
+
+	return events, count, err
+}
+
+func (s *Store) SetTunnel(ctx context.Context, tunnel *models.Tunnel) error {
+	tunnel.UpdatedAt = time.Now()
+
+	opts := options.Update().SetUpsert(true)
+	_, err := s.db.Collection("tunnels").UpdateOne(ctx, bson.M{"uid": tunnel.UID}, bson.M{"$set": tunnel}, opts)
+	return err
+}
+
+func (s *Store) GetTunnel(ctx context.Context, uid string) (*models.Tunnel, error) {
+	tunnel := new(models.Tunnel)
+	if err := s.db.Collection("tunnels").FindOne(ctx, bson.M{"uid": uid}).Decode(&tunnel); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, store.ErrTunnelNotFound
+		}
+
+		return nil, err
+	}
+
+	return tunnel, nil
+}
+
+// DeleteTunnel removes the tunnel of a device only if it is still held by the
+// given instance, since the device may have already reconnected to another one.
+func (s *Store) DeleteTunnel(ctx context.Context, uid, instance string) error {
+	res, err := s.db.Collection("tunnels").DeleteOne(ctx, bson.M{"uid": uid, "instance": instance})
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount < 1 {
+		return store.ErrTunnelNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) ListTunnels(ctx context.Context, instance string) ([]models.Tunnel, error) {
+	cursor, err := s.db.Collection("tunnels").Find(ctx, bson.M{"instance": instance})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tunnels := make([]models.Tunnel, 0)
+	for cursor.Next(ctx) {
+		tunnel := new(models.Tunnel)
+		if err := cursor.Decode(tunnel); err != nil {
+			return nil, err
+		}
+
+		tunnels = append(tunnels, *tunnel)
+	}
+
+	return tunnels, cursor.Err()
+}
+
+func (s *Store) ListUpdateChannels(ctx context.Context) ([]models.UpdateChannel, error) {
+	cursor, err := s.db.Collection("update_channels").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	channels := make([]models.UpdateChannel, 0)
+	for cursor.Next(ctx) {
+		channel := new(models.UpdateChannel)
+		if err := cursor.Decode(channel); err != nil {
+			return nil, err
+		}
+
+		channels = append(channels, *channel)
+	}
+
+	return channels, cursor.Err()
+}
+
+func (s *Store) GetUpdateChannel(ctx context.Context, name string) (*models.UpdateChannel, error) {
+	channel := new(models.UpdateChannel)
+	if err := s.db.Collection("update_channels").FindOne(ctx, bson.M{"name": name}).Decode(&channel); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, store.ErrUpdateChannelNotFound
+		}
+
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+func (s *Store) SetUpdateChannel(ctx context.Context, channel *models.UpdateChannel) error {
+	channel.UpdatedAt = time.Now()
+
+	opts := options.Update().SetUpsert(true)
+	_, err := s.db.Collection("update_channels").UpdateOne(ctx, bson.M{"name": channel.Name}, bson.M{"$set": channel}, opts)
+	return err
+}
+
+func (s *Store) DeleteUpdateChannel(ctx context.Context, name string) error {
+	res, err := s.db.Collection("update_channels").DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount < 1 {
+		return store.ErrUpdateChannelNotFound
+	}
+
+	return nil
+}
+
+// SetNamespaceUpdatePolicy sets the update policy of the namespace, a nil
+// policy removes it.
+func (s *Store) SetNamespaceUpdatePolicy(ctx context.Context, tenant string, policy *models.UpdatePolicy) error {
+	update := bson.M{"$set": bson.M{"settings.update": policy}}
+	if policy == nil {
+		update = bson.M{"$unset": bson.M{"settings.update": ""}}
+	}
+
+	res, err := s.db.Collection("namespaces").UpdateOne(ctx, bson.M{"tenant_id": tenant}, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return store.ErrNamespaceNoDocuments
+	}
+
+	return nil
+}
+
+// SetDeviceUpdatePolicy sets the update policy of the device, overriding the
+// one of its namespace. A nil policy removes it.
+func (s *Store) SetDeviceUpdatePolicy(ctx context.Context, uid models.UID, policy *models.UpdatePolicy) error {
+	update := bson.M{"$set": bson.M{"update": policy}}
+	if policy == nil {
+		update = bson.M{"$unset": bson.M{"update": ""}}
+	}
+
+	res, err := s.db.Collection("devices").UpdateOne(ctx, bson.M{"uid": uid}, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return store.ErrDeviceNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) CreateJob(ctx context.Context, job *models.Job, results []models.JobResult) error {
+	if _, err := s.db.Collection("jobs").InsertOne(ctx, job); err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(results))
+	for i := range results {
+		docs[i] = results[i]
+	}
+
+	_, err := s.db.Collection("job_results").InsertMany(ctx, docs)
+	return err
+}
+
+func (s *Store) GetJob(ctx context.Context, uid string) (*models.Job, error) {
+	query := bson.M{"uid": uid}
+
+	// Only match for the respective tenant if requested
+	if tenant := apicontext.TenantFromContext(ctx); tenant != nil {
+		query["tenant_id"] = tenant.ID
+	}
+
+	job := new(models.Job)
+	if err := s.db.Collection("jobs").FindOne(ctx, query).Decode(&job); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, store.ErrJobNotFound
+		}
+
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (s *Store) ListJobs(ctx context.Context, pagination paginator.Query) ([]models.Job, int, error) {
+	query := []bson.M{}
+
+	// Only match for the respective tenant if requested
+	if tenant := apicontext.TenantFromContext(ctx); tenant != nil {
+		query = append(query, bson.M{
+			"$match": bson.M{
+				"tenant_id": tenant.ID,
+			},
+		})
+	}
+
+	queryCount := append(query, bson.M{"$count": "count"})
+	count, err := aggregateCount(ctx, s.db.Collection("jobs"), queryCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = append(query, bson.M{
+		"$sort": bson.M{"created_at": -1},
+	})
+
+	query = append(query, buildPaginationQuery(pagination)...)
+
+	jobs := make([]models.Job, 0)
+	cursor, err := s.db.Collection("jobs").Aggregate(ctx, query)
+	if err != nil {
+		return jobs, count, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		job := new(models.Job)
+		if err := cursor.Decode(&job); err != nil {
+			return jobs, count, err
+		}
+
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, count, err
+}
+
+func (s *Store) FinishJob(ctx context.Context, uid string, at time.Time) error {
+	_, err := s.db.Collection("jobs").UpdateOne(ctx, bson.M{"uid": uid}, bson.M{"$set": bson.M{"status": models.JobStatusFinished, "finished_at": at}})
+	return err
+}
+
+// ListJobResults lists the results of a job, only those with the given
+// statuses if any.
+func (s *Store) ListJobResults(ctx context.Context, job string, statuses []string, pagination paginator.Query) ([]models.JobResult, int, error) {
+	match := bson.M{"job_uid": job}
+
+	if len(statuses) > 0 {
+		match["status"] = bson.M{"$in": statuses}
+	}
+
+	// Only match for the respective tenant if requested
+	if tenant := apicontext.TenantFromContext(ctx); tenant != nil {
+		match["tenant_id"] = tenant.ID
+	}
+
+	query := []bson.M{{"$match": match}}
+
+	queryCount := append(query, bson.M{"$count": "count"})
+	count, err := aggregateCount(ctx, s.db.Collection("job_results"), queryCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = append(query, bson.M{
+		"$sort": bson.M{"device_uid": 1},
+	})
+
+	query = append(query, buildPaginationQuery(pagination)...)
+
+	results := make([]models.JobResult, 0)
+	cursor, err := s.db.Collection("job_results").Aggregate(ctx, query)
+	if err != nil {
+		return results, count, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		result := new(models.JobResult)
+		if err := cursor.Decode(&result); err != nil {
+			return results, count, err
+		}
+
+		results = append(results, *result)
+	}
+
+	return results, count, err
+}
+
+func (s *Store) ListDeviceJobResults(ctx context.Context, device, status string) ([]models.JobResult, error) {
+	results := make([]models.JobResult, 0)
+
+	cursor, err := s.db.Collection("job_results").Find(ctx, bson.M{"device_uid": device, "status": status})
+	if err != nil {
+		return results, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		result := new(models.JobResult)
+		if err := cursor.Decode(&result); err != nil {
+			return results, err
+		}
+
+		results = append(results, *result)
+	}
+
+	return results, cursor.Err()
+}
+
+func (s *Store) UpdateJobResult(ctx context.Context, result *models.JobResult) error {
+	update := bson.M{
+		"status":     result.Status,
+		"exit_code":  result.ExitCode,
+		"stdout":     result.Stdout,
+		"stderr":     result.Stderr,
+		"truncated":  result.Truncated,
+		"error":      result.Error,
+		"started_at": result.StartedAt,
+		"duration":   result.Duration,
+	}
+
+	res, err := s.db.Collection("job_results").UpdateOne(ctx, bson.M{"job_uid": result.JobUID, "device_uid": result.DeviceUID}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return store.ErrJobNotFound
+	}
+
+	return nil
+}
+
+// StartJobResult moves the result of the job on the device from status to
+// running in a single update, so that only one caller runs it.
+func (s *Store) StartJobResult(ctx context.Context, job, device, status string, at time.Time) error {
+	res, err := s.db.Collection("job_results").UpdateOne(ctx,
+		bson.M{"job_uid": job, "device_uid": device, "status": status},
+		bson.M{"$set": bson.M{"status": models.JobResultRunning, "started_at": at}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return store.ErrJobNotFound
+	}
+
+	return nil
+}
+
+// buildCursorQuery returns the stages that sort a collection by the timeField
+// and idField pair in the given order (1 or -1) and skip every document up to
+// and including the one the cursor points to.
+func buildCursorQuery(cursor *paginator.Cursor, timeField, idField string, order int) []bson.M {
+	op := "$lt"
+	if order > 0 {
+		op = "$gt"
+	}
+
+	return []bson.M{
+		{
+			"$match": bson.M{
+				"$or": []bson.M{
+					{timeField: bson.M{op: cursor.Time}},
+					{timeField: cursor.Time, idField: bson.M{op: cursor.ID}},
+				},
+			},
+		},
+		{
+			"$sort": bson.D{{Key: timeField, Value: order}, {Key: idField, Value: order}},
+		},
+	}
+}
+
 func buildPaginationQuery(pagination paginator.Query) []bson.M {
 	if pagination.PerPage == -1 {
 		return nil
@@ -9,9 +9,11 @@ import (
 
 	"github.com/cnf/structhash"
 	"github.com/shellhub-io/shellhub/api/pkg/dbtest"
+	"github.com/shellhub-io/shellhub/api/store"
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -257,6 +259,31 @@ func TestUpdateDeviceStatus(t *testing.T) {
 	err = mongostore.UpdateDeviceStatus(ctx, models.UID(device.UID), true)
 	assert.NoError(t, err)
 }
+
+func TestSetDevicePresence(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("devices").InsertOne(ctx, models.Device{UID: "uid", TenantID: "tenant"})
+	assert.NoError(t, err)
+
+	connected := time.Now().Truncate(time.Millisecond).UTC()
+	disconnected := connected.Add(time.Minute)
+
+	err = mongostore.SetDevicePresence(ctx, models.UID("uid"), true, connected)
+	assert.NoError(t, err)
+	err = mongostore.SetDevicePresence(ctx, models.UID("uid"), false, disconnected)
+	assert.NoError(t, err)
+
+	device := new(models.Device)
+	err = db.Client().Database("test").Collection("devices").FindOne(ctx, bson.M{"uid": "uid"}).Decode(device)
+	assert.NoError(t, err)
+	assert.Equal(t, connected, device.LastConnected)
+	assert.Equal(t, disconnected, device.LastDisconnected)
+}
 func TestCreateSession(t *testing.T) {
 	db := dbtest.DBServer{}
 	defer db.Stop()
@@ -405,11 +432,120 @@ func TestListSessions(t *testing.T) {
 
 	_, err = mongostore.CreateSession(ctx, session)
 	assert.NoError(t, err)
-	sessions, count, err := mongostore.ListSessions(ctx, paginator.Query{Page: -1, PerPage: -1})
+	sessions, count, err := mongostore.ListSessions(ctx, paginator.Query{Page: -1, PerPage: -1}, nil, "", "")
 	assert.NoError(t, err)
 	assert.Equal(t, 1, count)
 	assert.NotEmpty(t, sessions)
 }
+func TestListSessionsWithCursor(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("namespaces").InsertOne(ctx, models.Namespace{Name: "name", Owner: "owner", TenantID: "tenant"})
+	assert.NoError(t, err)
+
+	device := models.Device{
+		UID:      "device",
+		Identity: &models.DeviceIdentity{MAC: "mac"},
+		TenantID: "tenant",
+		LastSeen: time.Now(),
+	}
+
+	err = mongostore.AddDevice(ctx, device, "")
+	assert.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for i, uid := range []string{"a", "b", "c"} {
+		_, err := db.Client().Database("test").Collection("sessions").InsertOne(ctx, models.Session{
+			UID:       uid,
+			DeviceUID: "device",
+			StartedAt: now.Add(time.Duration(i) * time.Second),
+		})
+		assert.NoError(t, err)
+	}
+
+	sessions, count, err := mongostore.ListSessions(ctx, paginator.Query{Page: 1, PerPage: 2}, nil, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 2, len(sessions))
+	assert.Equal(t, "c", sessions[0].UID)
+	assert.Equal(t, "b", sessions[1].UID)
+
+	cursor := paginator.NewCursor(sessions[1].StartedAt, sessions[1].UID)
+	sessions, count, err = mongostore.ListSessions(ctx, paginator.Query{PerPage: 2, Cursor: cursor}, nil, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 1, len(sessions))
+	assert.Equal(t, "a", sessions[0].UID)
+
+	_, _, err = mongostore.ListSessions(ctx, paginator.Query{PerPage: 2, Cursor: "invalid"}, nil, "", "")
+	assert.Equal(t, paginator.ErrInvalidCursor, err)
+}
+
+func TestListSessionsWithFilter(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("namespaces").InsertOne(ctx, models.Namespace{Name: "name", Owner: "owner", TenantID: "tenant"})
+	assert.NoError(t, err)
+
+	for _, name := range []string{"device1", "device2"} {
+		device := models.Device{
+			UID:      name,
+			Identity: &models.DeviceIdentity{MAC: name},
+			TenantID: "tenant",
+			LastSeen: time.Now(),
+		}
+
+		err = mongostore.AddDevice(ctx, device, name)
+		assert.NoError(t, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	sessions := []models.Session{
+		{UID: "a", DeviceUID: "device1", Username: "root", StartedAt: now.Add(-48 * time.Hour)},
+		{UID: "b", DeviceUID: "device1", Username: "root", StartedAt: now},
+		{UID: "c", DeviceUID: "device1", Username: "user", StartedAt: now},
+		{UID: "d", DeviceUID: "device2", Username: "root", StartedAt: now},
+	}
+
+	for _, session := range sessions {
+		_, err := db.Client().Database("test").Collection("sessions").InsertOne(ctx, session)
+		assert.NoError(t, err)
+	}
+
+	filters := []models.Filter{
+		{
+			Type:   "property",
+			Params: &models.PropertyParams{Name: "device.name", Operator: "eq", Value: "device1"},
+		},
+		{
+			Type:   "property",
+			Params: &models.PropertyParams{Name: "username", Operator: "eq", Value: "root"},
+		},
+		{
+			Type:   "property",
+			Params: &models.PropertyParams{Name: "started_at", Operator: "gt", Value: now.Add(-24 * time.Hour).Format(time.RFC3339)},
+		},
+		{
+			Type:   "operator",
+			Params: &models.OperatorParams{Name: "and"},
+		},
+	}
+
+	list, count, err := mongostore.ListSessions(ctx, paginator.Query{Page: -1, PerPage: -1}, filters, "started_at", "asc")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, len(list))
+	assert.Equal(t, "b", list[0].UID)
+}
+
 func TestSetSessionAuthenticated(t *testing.T) {
 	db := dbtest.DBServer{}
 	defer db.Stop()
@@ -461,6 +597,24 @@ func TestSetSessionAuthenticated(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSetSessionReason(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("sessions").InsertOne(ctx, models.Session{UID: "uid", Username: "user", TenantID: "tenant"})
+	assert.NoError(t, err)
+
+	err = mongostore.SetSessionReason(ctx, "uid", "terminated by user")
+	assert.NoError(t, err)
+
+	session, err := mongostore.GetSession(ctx, "uid")
+	assert.NoError(t, err)
+	assert.Equal(t, "terminated by user", session.Reason)
+}
+
 func TestKeepAliveSession(t *testing.T) {
 	db := dbtest.DBServer{}
 	defer db.Stop()
@@ -744,6 +898,9 @@ func TestGetDeviceByMac(t *testing.T) {
 	d, err := mongostore.GetDeviceByMac(ctx, "mac", "tenant", "pending")
 	assert.NoError(t, err)
 	assert.NotEmpty(t, d)
+
+	_, err = mongostore.GetDeviceByMac(ctx, "mac", "tenant", "accepted")
+	assert.Equal(t, store.ErrDeviceNotFound, err)
 }
 
 func TestGetDeviceByName(t *testing.T) {
@@ -985,6 +1142,82 @@ func TestListDevices(t *testing.T) {
 	assert.NotEmpty(t, devices)
 }
 
+func TestListDevicesPagesWithEqualSortField(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("namespaces").InsertOne(ctx, models.Namespace{Name: "name", Owner: "owner", TenantID: "tenant"})
+	assert.NoError(t, err)
+
+	// The pages never repeat nor skip devices sharing the sorted field
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for _, uid := range []string{"b", "a", "c"} {
+		_, err := db.Client().Database("test").Collection("devices").InsertOne(ctx, models.Device{
+			UID:       uid,
+			TenantID:  "tenant",
+			CreatedAt: now,
+			LastSeen:  now,
+		})
+		assert.NoError(t, err)
+	}
+
+	for _, sort := range []string{"", "created_at"} {
+		var uids []string
+		for page := 1; page <= 2; page++ {
+			devices, count, err := mongostore.ListDevices(ctx, paginator.Query{Page: page, PerPage: 2}, nil, "", sort, "desc")
+			assert.NoError(t, err)
+			assert.Equal(t, 3, count)
+
+			for _, device := range devices {
+				uids = append(uids, device.UID)
+			}
+		}
+
+		assert.Equal(t, []string{"c", "b", "a"}, uids)
+	}
+}
+
+func TestListDevicesWithInventoryFilter(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("namespaces").InsertOne(ctx, models.Namespace{Name: "name", Owner: "owner", TenantID: "tenant"})
+	assert.NoError(t, err)
+
+	for name, free := range map[string]int64{"device1": 512 << 20, "device2": 64 << 30} {
+		device := models.Device{
+			UID:      name,
+			Identity: &models.DeviceIdentity{MAC: name},
+			Info:     &models.DeviceInfo{ID: "linux", DiskTotal: 128 << 30, DiskFree: free},
+			TenantID: "tenant",
+			LastSeen: time.Now(),
+		}
+
+		err = mongostore.AddDevice(ctx, device, name)
+		assert.NoError(t, err)
+	}
+
+	filters := []models.Filter{
+		{
+			Type:   "property",
+			Params: &models.PropertyParams{Name: "info.disk_free", Operator: "lt", Value: "1073741824"},
+		},
+	}
+
+	devices, count, err := mongostore.ListDevices(ctx, paginator.Query{Page: -1, PerPage: -1}, filters, "", "last_seen", "asc")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, len(devices))
+	assert.Equal(t, "device1", devices[0].UID)
+	assert.Equal(t, int64(512<<20), devices[0].Info.DiskFree)
+}
+
 func TestListFirewallRules(t *testing.T) {
 	db := dbtest.DBServer{}
 	defer db.Stop()
@@ -1460,7 +1693,10 @@ func TestLoadLicense(t *testing.T) {
 	ctx := context.TODO()
 	mongostore := NewStore(db.Client().Database("test"))
 
-	err := mongostore.SaveLicense(ctx, &models.License{
+	_, err := mongostore.LoadLicense(ctx)
+	assert.Equal(t, store.ErrLicenseNotFound, err)
+
+	err = mongostore.SaveLicense(ctx, &models.License{
 		RawData:   []byte("bar"),
 		CreatedAt: time.Now().Local().Truncate(time.Millisecond),
 	})
@@ -1625,3 +1861,326 @@ func TestDeletePublicKey(t *testing.T) {
 	err = mongostore.DeletePublicKey(ctx, newKey.Fingerprint, newKey.TenantID)
 	assert.NoError(t, err)
 }
+
+func TestCreateAuditEvent(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	err := mongostore.CreateAuditEvent(ctx, &models.AuditEvent{
+		Time:     time.Now(),
+		TenantID: "tenant",
+		Actor:    "username",
+		Action:   "device.rename",
+		Target:   "uid",
+		Before:   "old",
+		After:    "new",
+	})
+	assert.NoError(t, err)
+}
+
+func TestListAuditEvents(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	for _, action := range []string{"device.rename", "device.delete", "device.rename"} {
+		err := mongostore.CreateAuditEvent(ctx, &models.AuditEvent{
+			Time:     time.Now(),
+			TenantID: "tenant",
+			Actor:    "username",
+			Action:   action,
+			Target:   "uid",
+		})
+		assert.NoError(t, err)
+	}
+
+	filters := []models.Filter{
+		{
+			Type:   "property",
+			Params: &models.PropertyParams{Name: "action", Operator: "eq", Value: "device.rename"},
+		},
+	}
+
+	events, count, err := mongostore.ListAuditEvents(ctx, paginator.Query{Page: -1, PerPage: -1}, filters)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 2, len(events))
+}
+
+func TestSetGetDeleteTunnel(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	err := mongostore.SetTunnel(ctx, &models.Tunnel{UID: "uid", Instance: "10.0.0.1:8080"})
+	assert.NoError(t, err)
+
+	err = mongostore.SetTunnel(ctx, &models.Tunnel{UID: "uid", Instance: "10.0.0.2:8080"})
+	assert.NoError(t, err)
+
+	tunnel, err := mongostore.GetTunnel(ctx, "uid")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.2:8080", tunnel.Instance)
+
+	err = mongostore.DeleteTunnel(ctx, "uid", "10.0.0.1:8080")
+	assert.Equal(t, store.ErrTunnelNotFound, err)
+
+	err = mongostore.DeleteTunnel(ctx, "uid", "10.0.0.2:8080")
+	assert.NoError(t, err)
+
+	_, err = mongostore.GetTunnel(ctx, "uid")
+	assert.Equal(t, store.ErrTunnelNotFound, err)
+}
+
+func TestListTunnels(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	for _, tunnel := range []models.Tunnel{
+		{UID: "uid1", Instance: "10.0.0.1:8080"},
+		{UID: "uid2", Instance: "10.0.0.2:8080"},
+		{UID: "uid3", Instance: "10.0.0.1:8080"},
+	} {
+		tunnel := tunnel
+		err := mongostore.SetTunnel(ctx, &tunnel)
+		assert.NoError(t, err)
+	}
+
+	tunnels, err := mongostore.ListTunnels(ctx, "10.0.0.1:8080")
+	assert.NoError(t, err)
+	assert.Len(t, tunnels, 2)
+}
+
+func TestUpdateChannels(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	err := mongostore.SetUpdateChannel(ctx, &models.UpdateChannel{Name: "stable", Version: "0.6.0", Rollout: 100})
+	assert.NoError(t, err)
+
+	err = mongostore.SetUpdateChannel(ctx, &models.UpdateChannel{Name: "stable", Version: "0.7.0", PreviousVersion: "0.6.0", Rollout: 5})
+	assert.NoError(t, err)
+
+	channel, err := mongostore.GetUpdateChannel(ctx, "stable")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.7.0", channel.Version)
+	assert.Equal(t, 5, channel.Rollout)
+
+	channels, err := mongostore.ListUpdateChannels(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(channels))
+
+	err = mongostore.DeleteUpdateChannel(ctx, "stable")
+	assert.NoError(t, err)
+
+	_, err = mongostore.GetUpdateChannel(ctx, "stable")
+	assert.Equal(t, store.ErrUpdateChannelNotFound, err)
+}
+
+func TestSetNamespaceUpdatePolicy(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("namespaces").InsertOne(ctx, models.Namespace{
+		Name:     "name",
+		Owner:    "owner",
+		TenantID: "tenant",
+		Settings: &models.NamespaceSettings{SessionRecord: true},
+	})
+	assert.NoError(t, err)
+
+	err = mongostore.SetNamespaceUpdatePolicy(ctx, "tenant", &models.UpdatePolicy{Channel: "beta"})
+	assert.NoError(t, err)
+
+	ns, err := mongostore.GetNamespace(ctx, "tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.UpdatePolicy{Channel: "beta"}, ns.Settings.Update)
+	assert.True(t, ns.Settings.SessionRecord)
+
+	err = mongostore.SetNamespaceUpdatePolicy(ctx, "tenant", nil)
+	assert.NoError(t, err)
+
+	ns, err = mongostore.GetNamespace(ctx, "tenant")
+	assert.NoError(t, err)
+	assert.Nil(t, ns.Settings.Update)
+
+	err = mongostore.SetNamespaceUpdatePolicy(ctx, "other", nil)
+	assert.Equal(t, store.ErrNamespaceNoDocuments, err)
+}
+
+func TestSetDeviceUpdatePolicy(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("devices").InsertOne(ctx, models.Device{UID: "uid", TenantID: "tenant"})
+	assert.NoError(t, err)
+
+	err = mongostore.SetDeviceUpdatePolicy(ctx, models.UID("uid"), &models.UpdatePolicy{Version: "0.6.0"})
+	assert.NoError(t, err)
+
+	device, err := mongostore.GetDevice(ctx, models.UID("uid"))
+	assert.NoError(t, err)
+	assert.Equal(t, &models.UpdatePolicy{Version: "0.6.0"}, device.Update)
+
+	err = mongostore.SetDeviceUpdatePolicy(ctx, models.UID("other"), nil)
+	assert.Equal(t, store.ErrDeviceNotFound, err)
+}
+
+func TestNamespaceUsage(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("namespaces").InsertOne(ctx, models.Namespace{Name: "name", Owner: "owner", TenantID: "tenant", MaxDevices: 3})
+	assert.NoError(t, err)
+
+	for name, status := range map[string]string{"device1": "accepted", "device2": "accepted", "device3": "pending"} {
+		_, err := db.Client().Database("test").Collection("devices").InsertOne(ctx, models.Device{UID: name, TenantID: "tenant", Status: status})
+		assert.NoError(t, err)
+	}
+
+	err = mongostore.SetNamespaceMaxDevices(ctx, "tenant", 5)
+	assert.NoError(t, err)
+
+	usage, err := mongostore.GetNamespaceUsage(ctx, "tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.NamespaceUsage{Devices: 2, PendingDevices: 1, MaxDevices: 5}, usage)
+
+	err = mongostore.SetNamespaceMaxDevices(ctx, "other", 5)
+	assert.Equal(t, store.ErrNamespaceNoDocuments, err)
+}
+
+func TestSetNamespaceShadowWrite(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	_, err := db.Client().Database("test").Collection("namespaces").InsertOne(ctx, models.Namespace{Name: "name", Owner: "owner", TenantID: "tenant"})
+	assert.NoError(t, err)
+
+	err = mongostore.SetNamespaceShadowWrite(ctx, "tenant", true)
+	assert.NoError(t, err)
+
+	ns, err := mongostore.GetNamespace(ctx, "tenant")
+	assert.NoError(t, err)
+	assert.True(t, ns.Settings.ShadowWrite)
+
+	err = mongostore.SetNamespaceShadowWrite(ctx, "other", true)
+	assert.Equal(t, store.ErrNamespaceNoDocuments, err)
+}
+
+func TestJobs(t *testing.T) {
+	db := dbtest.DBServer{}
+	defer db.Stop()
+
+	ctx := context.TODO()
+	mongostore := NewStore(db.Client().Database("test"))
+
+	job := &models.Job{UID: "job", TenantID: "tenant", Command: "uptime", User: "root", Status: models.JobStatusRunning, CreatedAt: time.Now()}
+	results := []models.JobResult{
+		{JobUID: "job", DeviceUID: "device1", TenantID: "tenant", Status: models.JobResultPending},
+		{JobUID: "job", DeviceUID: "device2", TenantID: "tenant", Status: models.JobResultWaiting},
+	}
+
+	err := mongostore.CreateJob(ctx, job, results)
+	assert.NoError(t, err)
+
+	returned, err := mongostore.GetJob(ctx, "job")
+	assert.NoError(t, err)
+	assert.Equal(t, "uptime", returned.Command)
+
+	_, err = mongostore.GetJob(ctx, "other")
+	assert.Equal(t, store.ErrJobNotFound, err)
+
+	jobs, count, err := mongostore.ListJobs(ctx, paginator.Query{Page: 1, PerPage: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, jobs, 1)
+
+	exitCode := 0
+	err = mongostore.UpdateJobResult(ctx, &models.JobResult{JobUID: "job", DeviceUID: "device1", Status: models.JobResultSuccess, ExitCode: &exitCode, Stdout: "up"})
+	assert.NoError(t, err)
+
+	err = mongostore.UpdateJobResult(ctx, &models.JobResult{JobUID: "job", DeviceUID: "device3", Status: models.JobResultSuccess})
+	assert.Equal(t, store.ErrJobNotFound, err)
+
+	returnedResults, count, err := mongostore.ListJobResults(ctx, "job", []string{models.JobResultSuccess}, paginator.Query{Page: 1, PerPage: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "tenant", returnedResults[0].TenantID)
+	assert.Equal(t, "up", returnedResults[0].Stdout)
+
+	waiting, err := mongostore.ListDeviceJobResults(ctx, "device2", models.JobResultWaiting)
+	assert.NoError(t, err)
+	assert.Len(t, waiting, 1)
+
+	err = mongostore.StartJobResult(ctx, "job", "device2", models.JobResultWaiting, time.Now())
+	assert.NoError(t, err)
+
+	err = mongostore.StartJobResult(ctx, "job", "device2", models.JobResultWaiting, time.Now())
+	assert.Equal(t, store.ErrJobNotFound, err)
+
+	waiting, err = mongostore.ListDeviceJobResults(ctx, "device2", models.JobResultWaiting)
+	assert.NoError(t, err)
+	assert.Len(t, waiting, 0)
+
+	err = mongostore.FinishJob(ctx, "job", time.Now())
+	assert.NoError(t, err)
+
+	returned, err = mongostore.GetJob(ctx, "job")
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobStatusFinished, returned.Status)
+}
+
+func TestBuildFilterQueryBool(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		match bson.M
+		err   error
+	}{
+		{"json true", true, bson.M{"$eq": true}, nil},
+		{"json false", false, bson.M{"$eq": false}, nil},
+		{"json number", float64(1), bson.M{"$eq": true}, nil},
+		{"string", "false", bson.M{"$eq": false}, nil},
+		{"invalid string", "maybe", nil, store.ErrInvalidFilter},
+		{"unsupported type", []interface{}{true}, nil, store.ErrInvalidFilter},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := buildFilterQuery([]models.Filter{{
+				Type:   "property",
+				Params: &models.PropertyParams{Name: "online", Operator: "bool", Value: tc.value},
+			}})
+			assert.Equal(t, tc.err, err)
+
+			if tc.err == nil {
+				assert.Equal(t, []bson.M{{"$match": bson.M{"$or": []bson.M{{"online": tc.match}}}}}, query)
+			}
+		})
+	}
+}
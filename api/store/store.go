@@ -3,16 +3,23 @@ package store
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/shellhub-io/shellhub/pkg/api/paginator"
 	"github.com/shellhub-io/shellhub/pkg/models"
 )
 
 var (
-	ErrDuplicateEmail       = errors.New("email address is already in use")
-	ErrRecordNotFound       = errors.New("public key not found")
-	ErrDuplicateFingerprint = errors.New("this fingerprint already exists")
-	ErrNamespaceNoDocuments = errors.New("mongo: no documents in result")
+	ErrDuplicateEmail        = errors.New("email address is already in use")
+	ErrRecordNotFound        = errors.New("public key not found")
+	ErrDuplicateFingerprint  = errors.New("this fingerprint already exists")
+	ErrNamespaceNoDocuments  = errors.New("mongo: no documents in result")
+	ErrTunnelNotFound        = errors.New("tunnel not found")
+	ErrUpdateChannelNotFound = errors.New("update channel not found")
+	ErrLicenseNotFound       = errors.New("license not found")
+	ErrJobNotFound           = errors.New("job not found")
+	ErrDeviceNotFound        = errors.New("device not found")
+	ErrInvalidFilter         = errors.New("invalid filter")
 )
 
 type Store interface {
@@ -23,11 +30,13 @@ type Store interface {
 	RenameDevice(ctx context.Context, uid models.UID, name string) error
 	LookupDevice(ctx context.Context, namespace, name string) (*models.Device, error)
 	UpdateDeviceStatus(ctx context.Context, uid models.UID, online bool) error
+	SetDevicePresence(ctx context.Context, uid models.UID, online bool, at time.Time) error
 	UpdatePendingStatus(ctx context.Context, uid models.UID, status string) error
-	ListSessions(ctx context.Context, pagination paginator.Query) ([]models.Session, int, error)
+	ListSessions(ctx context.Context, pagination paginator.Query, filters []models.Filter, sort string, order string) ([]models.Session, int, error)
 	GetSession(ctx context.Context, uid models.UID) (*models.Session, error)
 	CreateSession(ctx context.Context, session models.Session) (*models.Session, error)
 	SetSessionAuthenticated(ctx context.Context, uid models.UID, authenticated bool) error
+	SetSessionReason(ctx context.Context, uid models.UID, reason string) error
 	KeepAliveSession(ctx context.Context, uid models.UID) error
 	DeactivateSession(ctx context.Context, uid models.UID) error
 	RecordSession(ctx context.Context, uid models.UID, record string, width, height int) error
@@ -71,4 +80,27 @@ type Store interface {
 	AddNamespaceUser(ctx context.Context, namespace, ID string) (*models.Namespace, error)
 	RemoveNamespaceUser(ctx context.Context, namespace, ID string) (*models.Namespace, error)
 	GetSomeNamespace(ctx context.Context, ID string) (*models.Namespace, error)
+	CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error
+	ListAuditEvents(ctx context.Context, pagination paginator.Query, filters []models.Filter) ([]models.AuditEvent, int, error)
+	SetTunnel(ctx context.Context, tunnel *models.Tunnel) error
+	GetTunnel(ctx context.Context, uid string) (*models.Tunnel, error)
+	DeleteTunnel(ctx context.Context, uid, instance string) error
+	ListTunnels(ctx context.Context, instance string) ([]models.Tunnel, error)
+	ListUpdateChannels(ctx context.Context) ([]models.UpdateChannel, error)
+	GetUpdateChannel(ctx context.Context, name string) (*models.UpdateChannel, error)
+	SetUpdateChannel(ctx context.Context, channel *models.UpdateChannel) error
+	DeleteUpdateChannel(ctx context.Context, name string) error
+	SetNamespaceUpdatePolicy(ctx context.Context, tenant string, policy *models.UpdatePolicy) error
+	SetDeviceUpdatePolicy(ctx context.Context, uid models.UID, policy *models.UpdatePolicy) error
+	GetNamespaceUsage(ctx context.Context, tenant string) (*models.NamespaceUsage, error)
+	SetNamespaceMaxDevices(ctx context.Context, tenant string, max int) error
+	SetNamespaceShadowWrite(ctx context.Context, tenant string, allow bool) error
+	CreateJob(ctx context.Context, job *models.Job, results []models.JobResult) error
+	GetJob(ctx context.Context, uid string) (*models.Job, error)
+	ListJobs(ctx context.Context, pagination paginator.Query) ([]models.Job, int, error)
+	FinishJob(ctx context.Context, uid string, at time.Time) error
+	ListJobResults(ctx context.Context, job string, statuses []string, pagination paginator.Query) ([]models.JobResult, int, error)
+	ListDeviceJobResults(ctx context.Context, device, status string) ([]models.JobResult, error)
+	UpdateJobResult(ctx context.Context, result *models.JobResult) error
+	StartJobResult(ctx context.Context, job, device, status string, at time.Time) error
 }
@@ -0,0 +1,39 @@
+package tunnelmngr
+
+import (
+	"context"
+
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/pkg/models"
+)
+
+type Service interface {
+	RegisterTunnel(ctx context.Context, uid, instance string) error
+	UnregisterTunnel(ctx context.Context, uid, instance string) error
+	GetTunnel(ctx context.Context, uid string) (*models.Tunnel, error)
+	ListTunnels(ctx context.Context, instance string) ([]models.Tunnel, error)
+}
+
+type service struct {
+	store store.Store
+}
+
+func NewService(store store.Store) Service {
+	return &service{store}
+}
+
+func (s *service) RegisterTunnel(ctx context.Context, uid, instance string) error {
+	return s.store.SetTunnel(ctx, &models.Tunnel{UID: uid, Instance: instance})
+}
+
+func (s *service) UnregisterTunnel(ctx context.Context, uid, instance string) error {
+	return s.store.DeleteTunnel(ctx, uid, instance)
+}
+
+func (s *service) GetTunnel(ctx context.Context, uid string) (*models.Tunnel, error) {
+	return s.store.GetTunnel(ctx, uid)
+}
+
+func (s *service) ListTunnels(ctx context.Context, instance string) ([]models.Tunnel, error) {
+	return s.store.ListTunnels(ctx, instance)
+}
@@ -0,0 +1,82 @@
+package tunnelmngr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/api/store/mocks"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterTunnel(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	mock.On("SetTunnel", ctx, &models.Tunnel{UID: "uid", Instance: "10.0.0.1:8080"}).
+		Return(nil).Once()
+
+	err := s.RegisterTunnel(ctx, "uid", "10.0.0.1:8080")
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestUnregisterTunnel(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	mock.On("DeleteTunnel", ctx, "uid", "10.0.0.1:8080").
+		Return(nil).Once()
+	mock.On("DeleteTunnel", ctx, "uid", "10.0.0.2:8080").
+		Return(store.ErrTunnelNotFound).Once()
+
+	err := s.UnregisterTunnel(ctx, "uid", "10.0.0.1:8080")
+	assert.NoError(t, err)
+
+	err = s.UnregisterTunnel(ctx, "uid", "10.0.0.2:8080")
+	assert.Equal(t, store.ErrTunnelNotFound, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestGetTunnel(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	tunnel := &models.Tunnel{UID: "uid", Instance: "10.0.0.1:8080"}
+
+	mock.On("GetTunnel", ctx, "uid").
+		Return(tunnel, nil).Once()
+
+	returnedTunnel, err := s.GetTunnel(ctx, "uid")
+	assert.NoError(t, err)
+	assert.Equal(t, tunnel, returnedTunnel)
+
+	mock.AssertExpectations(t)
+}
+
+func TestListTunnels(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	tunnels := []models.Tunnel{{UID: "uid", Instance: "10.0.0.1:8080"}}
+
+	mock.On("ListTunnels", ctx, "10.0.0.1:8080").
+		Return(tunnels, nil).Once()
+
+	returnedTunnels, err := s.ListTunnels(ctx, "10.0.0.1:8080")
+	assert.NoError(t, err)
+	assert.Equal(t, tunnels, returnedTunnels)
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,193 @@
+package updatemngr
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+
+	"github.com/Masterminds/semver"
+	"github.com/shellhub-io/shellhub/api/auditlog"
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// DefaultChannel is the channel followed when no policy selects one.
+const DefaultChannel = "stable"
+
+var (
+	ErrInvalidChannel    = errors.New("invalid update channel")
+	ErrInvalidPolicy     = errors.New("invalid update policy")
+	ErrDeviceNotFound    = errors.New("device not found")
+	ErrNamespaceNotFound = errors.New("namespace not found")
+)
+
+type Service interface {
+	ListChannels(ctx context.Context) ([]models.UpdateChannel, error)
+	SetChannel(ctx context.Context, channel *models.UpdateChannel) error
+	DeleteChannel(ctx context.Context, name string) error
+	SetNamespacePolicy(ctx context.Context, tenant string, policy *models.UpdatePolicy) error
+	SetDevicePolicy(ctx context.Context, uid models.UID, tenant string, policy *models.UpdatePolicy) error
+	TargetVersion(ctx context.Context, device *models.Device, namespace *models.Namespace, current string) (string, error)
+}
+
+type service struct {
+	store store.Store
+}
+
+func NewService(store store.Store) Service {
+	return &service{store}
+}
+
+func (s *service) ListChannels(ctx context.Context) ([]models.UpdateChannel, error) {
+	return s.store.ListUpdateChannels(ctx)
+}
+
+func (s *service) SetChannel(ctx context.Context, channel *models.UpdateChannel) error {
+	if err := validator.New().Struct(channel); err != nil {
+		return ErrInvalidChannel
+	}
+
+	for _, version := range []string{channel.Version, channel.PreviousVersion} {
+		if _, err := semver.NewVersion(version); version != "" && err != nil {
+			return ErrInvalidChannel
+		}
+	}
+
+	return s.store.SetUpdateChannel(ctx, channel)
+}
+
+func (s *service) DeleteChannel(ctx context.Context, name string) error {
+	return s.store.DeleteUpdateChannel(ctx, name)
+}
+
+func (s *service) SetNamespacePolicy(ctx context.Context, tenant string, policy *models.UpdatePolicy) error {
+	if err := s.validatePolicy(ctx, policy); err != nil {
+		return err
+	}
+
+	if err := s.store.SetNamespaceUpdatePolicy(ctx, tenant, policy); err != nil {
+		if err == store.ErrNamespaceNoDocuments {
+			return ErrNamespaceNotFound
+		}
+
+		return err
+	}
+
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: tenant,
+		Action:   auditlog.ActionNamespaceSettings,
+		Target:   "update",
+		After:    policy,
+	})
+
+	return nil
+}
+
+func (s *service) SetDevicePolicy(ctx context.Context, uid models.UID, tenant string, policy *models.UpdatePolicy) error {
+	device, err := s.store.GetDeviceByUID(ctx, uid, tenant)
+	if err != nil {
+		return ErrDeviceNotFound
+	}
+
+	if err := s.validatePolicy(ctx, policy); err != nil {
+		return err
+	}
+
+	if err := s.store.SetDeviceUpdatePolicy(ctx, uid, policy); err != nil {
+		if err == store.ErrDeviceNotFound {
+			return ErrDeviceNotFound
+		}
+
+		return err
+	}
+
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		TenantID: tenant,
+		Action:   auditlog.ActionDeviceUpdatePolicy,
+		Target:   string(uid),
+		Before:   device.Update,
+		After:    policy,
+	})
+
+	return nil
+}
+
+// validatePolicy checks that a policy either pins an existing version or
+// follows an existing channel.
+func (s *service) validatePolicy(ctx context.Context, policy *models.UpdatePolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	if (policy.Channel == "") == (policy.Version == "") {
+		return ErrInvalidPolicy
+	}
+
+	if policy.Version != "" {
+		if _, err := semver.NewVersion(policy.Version); err != nil {
+			return ErrInvalidPolicy
+		}
+
+		return nil
+	}
+
+	if _, err := s.store.GetUpdateChannel(ctx, policy.Channel); err != nil {
+		if err == store.ErrUpdateChannelNotFound {
+			return ErrInvalidChannel
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// TargetVersion returns the agent version assigned to the device, which is
+// running the current version. The policy of the device takes precedence over
+// the one of its namespace, and devices without any follow the default
+// channel. An empty version is returned when the channel does not exist.
+func (s *service) TargetVersion(ctx context.Context, device *models.Device, namespace *models.Namespace, current string) (string, error) {
+	policy := device.Update
+	if policy == nil && namespace.Settings != nil {
+		policy = namespace.Settings.Update
+	}
+
+	if policy == nil {
+		policy = &models.UpdatePolicy{Channel: DefaultChannel}
+	}
+
+	if policy.Version != "" {
+		return policy.Version, nil
+	}
+
+	channel, err := s.store.GetUpdateChannel(ctx, policy.Channel)
+	if err != nil {
+		if err == store.ErrUpdateChannelNotFound {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	if inRollout(device.UID, channel.Rollout) {
+		return channel.Version, nil
+	}
+
+	// Devices left out of the rollout stay where they are unless the
+	// channel tells the version they should be on
+	if channel.PreviousVersion != "" {
+		return channel.PreviousVersion, nil
+	}
+
+	return current, nil
+}
+
+// inRollout reports whether the device falls in the given percentage of the
+// devices. The same devices are kept in as the percentage grows.
+func inRollout(uid string, percentage int) bool {
+	h := fnv.New32a()
+	h.Write([]byte(uid)) // nolint:errcheck
+
+	return int(h.Sum32()%100) < percentage
+}
@@ -0,0 +1,129 @@
+package updatemngr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/api/store/mocks"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+)
+
+func TestTargetVersion(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	namespace := &models.Namespace{
+		TenantID: "tenant",
+		Settings: &models.NamespaceSettings{Update: &models.UpdatePolicy{Channel: "beta"}},
+	}
+
+	mock.On("GetUpdateChannel", ctx, "beta").
+		Return(&models.UpdateChannel{Name: "beta", Version: "0.7.0", PreviousVersion: "0.6.0", Rollout: 100}, nil).Once()
+	mock.On("GetUpdateChannel", ctx, "stable").
+		Return(nil, store.ErrUpdateChannelNotFound).Once()
+
+	version, err := s.TargetVersion(ctx, &models.Device{UID: "uid"}, namespace, "0.5.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.7.0", version)
+
+	// The policy of the device overrides the one of the namespace
+	version, err = s.TargetVersion(ctx, &models.Device{UID: "uid", Update: &models.UpdatePolicy{Version: "0.5.2"}}, namespace, "0.5.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.5.2", version)
+
+	version, err = s.TargetVersion(ctx, &models.Device{UID: "uid"}, &models.Namespace{TenantID: "tenant"}, "0.5.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "", version)
+
+	mock.AssertExpectations(t)
+}
+
+func TestTargetVersionRollout(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	mock.On("GetUpdateChannel", ctx, "stable").
+		Return(&models.UpdateChannel{Name: "stable", Version: "0.7.0", Rollout: 5}, nil)
+
+	updated := 0
+	for i := 0; i < 1000; i++ {
+		device := &models.Device{UID: fmt.Sprintf("device%d", i)}
+
+		version, err := s.TargetVersion(ctx, device, &models.Namespace{}, "0.6.0")
+		assert.NoError(t, err)
+
+		if version == "0.7.0" {
+			updated++
+		} else {
+			assert.Equal(t, "0.6.0", version)
+		}
+	}
+
+	assert.InDelta(t, 50, updated, 25)
+}
+
+func TestSetNamespacePolicy(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	mock.On("GetUpdateChannel", ctx, "nightly").
+		Return(nil, store.ErrUpdateChannelNotFound).Once()
+	mock.On("SetNamespaceUpdatePolicy", ctx, "tenant", &models.UpdatePolicy{Version: "0.6.0"}).
+		Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
+
+	err := s.SetNamespacePolicy(ctx, "tenant", &models.UpdatePolicy{Channel: "nightly"})
+	assert.Equal(t, ErrInvalidChannel, err)
+
+	err = s.SetNamespacePolicy(ctx, "tenant", &models.UpdatePolicy{Channel: "stable", Version: "0.6.0"})
+	assert.Equal(t, ErrInvalidPolicy, err)
+
+	err = s.SetNamespacePolicy(ctx, "tenant", &models.UpdatePolicy{Version: "0.6.0"})
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func TestSetDevicePolicy(t *testing.T) {
+	mock := &mocks.Store{}
+	s := NewService(store.Store(mock))
+
+	ctx := context.TODO()
+
+	device := &models.Device{UID: "uid", TenantID: "tenant", Update: &models.UpdatePolicy{Channel: "stable"}}
+	policy := &models.UpdatePolicy{Version: "0.6.0"}
+
+	mock.On("GetDeviceByUID", ctx, models.UID("other"), "tenant").
+		Return(nil, store.ErrDeviceNotFound).Once()
+	mock.On("GetDeviceByUID", ctx, models.UID("uid"), "tenant").
+		Return(device, nil).Twice()
+	mock.On("SetDeviceUpdatePolicy", ctx, models.UID("uid"), policy).
+		Return(store.ErrDeviceNotFound).Once()
+	mock.On("SetDeviceUpdatePolicy", ctx, models.UID("uid"), policy).
+		Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.MatchedBy(func(event *models.AuditEvent) bool {
+		return event.Action == "device.update.policy" && event.Target == "uid" && event.Before == device.Update && event.After == policy
+	})).Return(nil).Once()
+
+	err := s.SetDevicePolicy(ctx, models.UID("other"), "tenant", policy)
+	assert.Equal(t, ErrDeviceNotFound, err)
+
+	// The device is removed meanwhile
+	err = s.SetDevicePolicy(ctx, models.UID("uid"), "tenant", policy)
+	assert.Equal(t, ErrDeviceNotFound, err)
+
+	err = s.SetDevicePolicy(ctx, models.UID("uid"), "tenant", policy)
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
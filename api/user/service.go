@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 
+	"github.com/shellhub-io/shellhub/api/auditlog"
 	"github.com/shellhub-io/shellhub/api/store"
+	"github.com/shellhub-io/shellhub/pkg/models"
 )
 
 var ErrUnauthorized = errors.New("unauthorized")
@@ -45,6 +47,8 @@ func (s *service) UpdateDataUser(ctx context.Context, username, email, currentPa
 		return invalidFields, ErrUnauthorized
 	}
 
+	before := user
+
 	var checkName, checkEmail bool
 
 	user, err = s.store.GetUserByUsername(ctx, username)
@@ -60,5 +64,31 @@ func (s *service) UpdateDataUser(ctx context.Context, username, email, currentPa
 	if checkName || checkEmail {
 		return invalidFields, ErrConflict
 	}
-	return invalidFields, s.store.UpdateUser(ctx, username, email, currentPassword, newPassword, ID)
+
+	if err := s.store.UpdateUser(ctx, username, email, currentPassword, newPassword, ID); err != nil {
+		return invalidFields, err
+	}
+
+	// Only the changed fields are recorded and the password is never stored
+	changedBefore := map[string]string{}
+	changedAfter := map[string]string{}
+	if username != "" && username != before.Username {
+		changedBefore["username"], changedAfter["username"] = before.Username, username
+	}
+	if email != "" && email != before.Email {
+		changedBefore["email"], changedAfter["email"] = before.Email, email
+	}
+	if newPassword != "" && newPassword != currentPassword {
+		changedAfter["password"] = "changed"
+	}
+
+	auditlog.Record(ctx, s.store, models.AuditEvent{
+		Actor:  before.Username,
+		Action: auditlog.ActionUserUpdate,
+		Target: ID,
+		Before: changedBefore,
+		After:  changedAfter,
+	})
+
+	return invalidFields, nil
 }
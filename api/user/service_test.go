@@ -8,6 +8,7 @@ import (
 	"github.com/shellhub-io/shellhub/api/store/mocks"
 	"github.com/shellhub-io/shellhub/pkg/models"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 )
 
 func TestUpdateDataUser(t *testing.T) {
@@ -31,6 +32,7 @@ func TestUpdateDataUser(t *testing.T) {
 	mock.On("GetUserByEmail", ctx, updateUser1.Email).Return(user, nil).Once()
 	mock.On("GetUserByID", ctx, updateUser1.ID).Return(user, nil).Once()
 	mock.On("UpdateUser", ctx, updateUser1.Username, updateUser1.Email, updateUser1.Password, updateUser1.Password, updateUser1.ID).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	_, err := s.UpdateDataUser(ctx, updateUser1.Username, updateUser1.Email, updateUser1.Password, updateUser1.Password, updateUser1.ID)
 
@@ -42,6 +44,7 @@ func TestUpdateDataUser(t *testing.T) {
 	mock.On("GetUserByEmail", ctx, updateUser2.Email).Return(user2, nil).Once()
 	mock.On("GetUserByID", ctx, updateUser2.ID).Return(user2, nil).Once()
 	mock.On("UpdateUser", ctx, updateUser2.Username, updateUser2.Email, updateUser2.Password, updateUser2.Password, updateUser2.ID).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	_, err = s.UpdateDataUser(ctx, updateUser2.Username, updateUser2.Email, updateUser2.Password, updateUser2.Password, updateUser2.ID)
 
@@ -56,6 +59,7 @@ func TestUpdateDataUser(t *testing.T) {
 	mock.On("GetUserByEmail", ctx, updateUser3.Email).Return(user3, nil).Once()
 	mock.On("GetUserByID", ctx, updateUser3.ID).Return(user3, nil).Once()
 	mock.On("UpdateUser", ctx, updateUser3.Username, updateUser3.Email, oldPassword, newPassword, updateUser3.ID).Return(nil).Once()
+	mock.On("CreateAuditEvent", ctx, testifymock.AnythingOfType("*models.AuditEvent")).Return(nil).Once()
 
 	_, err = s.UpdateDataUser(ctx, updateUser3.Username, updateUser3.Email, oldPassword, newPassword, updateUser3.ID)
 
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/shellhub-io/shellhub/pkg/wsconnadapter"
+)
+
+// connectURL is the gateway endpoint opening TCP connections to devices.
+const connectURL = "/ws/tcp"
+
+// runConnectCommand implements the connect command, listening on a local
+// port and forwarding every connection to a port of a device through the
+// ShellHub server.
+func runConnectCommand(args []string) error {
+	flags := flag.NewFlagSet("connect", flag.ExitOnError)
+	server := flags.String("server", os.Getenv("SHELLHUB_SERVER"), "address of the ShellHub server, e.g. https://shellhub.example.com")
+	token := flags.String("token", os.Getenv("SHELLHUB_TOKEN"), "token of the user, as returned by the login")
+	device := flags.String("device", "", "device to connect to, as <namespace>.<name> or its UID")
+	port := flags.Int("port", 0, "port of the device to connect to")
+	local := flags.String("local", "", "local port or address to listen on, defaults to the port of the device")
+	flags.Parse(args) // nolint:errcheck
+
+	switch {
+	case *server == "":
+		return errors.New("missing server address, use -server or SHELLHUB_SERVER")
+	case *token == "":
+		return errors.New("missing token, use -token or SHELLHUB_TOKEN")
+	case *device == "":
+		return errors.New("missing device, use -device")
+	case *port <= 0 || *port > 65535:
+		return errors.New("missing or invalid port, use -port")
+	}
+
+	endpoint, err := connectEndpoint(*server, *device, *port)
+	if err != nil {
+		return err
+	}
+
+	addr := *local
+	if addr == "" {
+		addr = strconv.Itoa(*port)
+	}
+
+	// A bare port only listens on the loopback interface
+	if _, err := strconv.Atoi(addr); err == nil {
+		addr = net.JoinHostPort("127.0.0.1", addr)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	fmt.Fprintf(os.Stderr, "Forwarding %s to port %d of %s\n", listener.Addr(), *port, *device)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+*token)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			if err := forward(conn, endpoint, header); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to %s: %s\n", *device, err)
+			}
+		}()
+	}
+}
+
+// connectEndpoint builds the websocket URL to connect to port of device.
+func connectEndpoint(server, device string, port int) (string, error) {
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("unsupported server scheme %q", u.Scheme)
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + connectURL
+	u.RawQuery = url.Values{"device": {device}, "port": {strconv.Itoa(port)}}.Encode()
+
+	return u.String(), nil
+}
+
+// forward copies the data between conn and a new connection to the device.
+func forward(conn net.Conn, endpoint string, header http.Header) error {
+	defer conn.Close()
+
+	ws, resp, err := websocket.DefaultDialer.Dial(endpoint, header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("%w: %s", err, resp.Status)
+		}
+
+		return err
+	}
+
+	remote := wsconnadapter.New(ws)
+	defer remote.Close()
+
+	go func() {
+		io.Copy(remote, conn) // nolint:errcheck
+		remote.Close()
+	}()
+
+	io.Copy(conn, remote) // nolint:errcheck
+
+	return nil
+}
@@ -0,0 +1,10 @@
+module github.com/shellhub-io/shellhub/cli
+
+go 1.14
+
+require (
+	github.com/gorilla/websocket v1.4.2
+	github.com/shellhub-io/shellhub v0.5.2
+)
+
+replace github.com/shellhub-io/shellhub => ../
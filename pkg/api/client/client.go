@@ -2,6 +2,8 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"math"
@@ -9,9 +11,12 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/parnurzeal/gorequest"
+	"github.com/shellhub-io/shellhub/pkg/backoff"
 	"github.com/shellhub-io/shellhub/pkg/models"
 	"github.com/sirupsen/logrus"
 )
@@ -21,12 +26,16 @@ const (
 
 	ConnectionFailedErr = "Connection failed"
 	NotFoundErr         = "Not found"
+	ForbiddenErr        = "Forbidden"
+	ConflictErr         = "Conflict"
 	UnknownErr          = "Unknown error"
 )
 
+// ErrUnauthorized is returned when the server rejects the device token.
+var ErrUnauthorized = errors.New("unauthorized")
+
 func NewClient(opts ...Opt) Client {
 	retryClient := retryablehttp.NewClient()
-	retryClient.HTTPClient = &http.Client{}
 	retryClient.RetryMax = math.MaxInt32
 	retryClient.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
 		if _, ok := err.(net.Error); ok {
@@ -35,6 +44,14 @@ func NewClient(opts ...Opt) Client {
 
 		return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
 	}
+	retryClient.Backoff = func(min, max time.Duration, attempt int, resp *http.Response) time.Duration {
+		// Honor the delay requested by the server
+		if resp != nil && resp.Header.Get("Retry-After") != "" {
+			return retryablehttp.DefaultBackoff(min, max, attempt, resp)
+		}
+
+		return backoff.Duration(min, max, backoff.DefaultFactor, attempt)
+	}
 
 	gorequest.DisableTransportSwap = true
 
@@ -46,6 +63,7 @@ func NewClient(opts ...Opt) Client {
 		port:   apiPort,
 		scheme: apiScheme,
 		http:   httpClient,
+		proxy:  http.ProxyFromEnvironment,
 	}
 
 	for _, opt := range opts {
@@ -54,6 +72,20 @@ func NewClient(opts ...Opt) Client {
 		}
 	}
 
+	tlsConfig := c.tlsConfig()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = c.proxy
+	transport.TLSClientConfig = tlsConfig
+
+	retryClient.HTTPClient = &http.Client{Transport: transport}
+
+	c.dialer = &websocket.Dialer{
+		Proxy:            c.proxy,
+		TLSClientConfig:  tlsConfig,
+		HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+	}
+
 	if c.logger != nil {
 		retryClient.Logger = &LeveledLogger{c.logger}
 	}
@@ -71,7 +103,13 @@ type client struct {
 	host   string
 	port   int
 	http   *gorequest.SuperAgent
+	dialer *websocket.Dialer
+	proxy  func(*http.Request) (*url.URL, error)
 	logger *logrus.Logger
+
+	rootCAs      *x509.CertPool
+	certificates []tls.Certificate
+	pins         [][]byte
 }
 
 func (c *client) ListDevices() ([]models.Device, error) {
@@ -3,7 +3,12 @@
 package client
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/shellhub-io/shellhub/pkg/models"
 )
@@ -20,12 +25,38 @@ type Client interface {
 }
 
 type internalAPI interface {
-	LookupDevice()
+	LookupDevice(namespace, name string) (*models.Device, error)
 	GetPublicKey(fingerprint, tenant string) (*models.PublicKey, error)
 	CreatePrivateKey() (*models.PrivateKey, error)
+	GetTunnel(uid string) (*models.Tunnel, error)
+	RegisterTunnel(uid, instance string) error
+	UnregisterTunnel(uid, instance string) error
+	ListTunnels(instance string) ([]models.Tunnel, error)
+	DeviceOnline(uid string, at time.Time) error
+	DeviceOffline(uid string, at time.Time) error
+	GetSession(uid string) (*models.Session, error)
+	AuthorizeShadow(uid, username string, write bool) (*models.Session, error)
+	GetJob(uid string) (*models.Job, error)
+	SetJobResult(result *models.JobResult) error
+	StartJobResult(job, device, status string) error
+	ListWaitingJobResults(device string) ([]models.JobResult, error)
 }
 
-func (c *client) LookupDevice() {
+func (c *client) LookupDevice(namespace, name string) (*models.Device, error) {
+	var device *models.Device
+	resp, _, errs := c.http.Get(buildURL(c, "/internal/lookup")).
+		Query(url.Values{"domain": {namespace}, "name": {name}}.Encode()).
+		EndStruct(&device)
+	if len(errs) > 0 {
+		return nil, errors.New(ConnectionFailedErr)
+	}
+
+	// The lookup responds with an empty body when there is no such device
+	if resp.StatusCode != http.StatusOK || device == nil || device.UID == "" {
+		return nil, errors.New(NotFoundErr)
+	}
+
+	return device, nil
 }
 
 func (c *client) GetPublicKey(fingerprint, tenant string) (*models.PublicKey, error) {
@@ -47,3 +78,187 @@ func (c *client) CreatePrivateKey() (*models.PrivateKey, error) {
 
 	return privKey, nil
 }
+
+func (c *client) GetTunnel(uid string) (*models.Tunnel, error) {
+	var tunnel *models.Tunnel
+	resp, _, errs := c.http.Get(buildURL(c, fmt.Sprintf("/internal/tunnels/%s", uid))).EndStruct(&tunnel)
+	if len(errs) > 0 {
+		return nil, errors.New(ConnectionFailedErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return tunnel, nil
+	case http.StatusNotFound:
+		return nil, errors.New(NotFoundErr)
+	}
+
+	return nil, errors.New(UnknownErr)
+}
+
+func (c *client) RegisterTunnel(uid, instance string) error {
+	resp, _, errs := c.http.Put(buildURL(c, fmt.Sprintf("/internal/tunnels/%s", uid))).Send(map[string]string{"instance": instance}).End()
+	if len(errs) > 0 {
+		return errors.New(ConnectionFailedErr)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(UnknownErr)
+	}
+
+	return nil
+}
+
+func (c *client) UnregisterTunnel(uid, instance string) error {
+	resp, _, errs := c.http.Delete(buildURL(c, fmt.Sprintf("/internal/tunnels/%s", uid))).Query("instance=" + url.QueryEscape(instance)).End()
+	if len(errs) > 0 {
+		return errors.New(ConnectionFailedErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return errors.New(NotFoundErr)
+	}
+
+	return errors.New(UnknownErr)
+}
+
+func (c *client) ListTunnels(instance string) ([]models.Tunnel, error) {
+	var tunnels []models.Tunnel
+	resp, _, errs := c.http.Get(buildURL(c, "/internal/tunnels")).Query("instance=" + url.QueryEscape(instance)).EndStruct(&tunnels)
+	if len(errs) > 0 {
+		return nil, errors.New(ConnectionFailedErr)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(UnknownErr)
+	}
+
+	return tunnels, nil
+}
+
+func (c *client) GetSession(uid string) (*models.Session, error) {
+	var session *models.Session
+	resp, _, errs := c.http.Get(buildURL(c, fmt.Sprintf("/api/sessions/%s", uid))).EndStruct(&session)
+	if len(errs) > 0 {
+		return nil, errors.New(ConnectionFailedErr)
+	}
+
+	if resp.StatusCode != http.StatusOK || session == nil || session.UID == "" {
+		return nil, errors.New(NotFoundErr)
+	}
+
+	return session, nil
+}
+
+// AuthorizeShadow checks whether the user may attach to the session, typing
+// into it when write is set.
+func (c *client) AuthorizeShadow(uid, username string, write bool) (*models.Session, error) {
+	var session *models.Session
+	resp, _, errs := c.http.Get(buildURL(c, fmt.Sprintf("/internal/sessions/%s/shadow", uid))).
+		Query(url.Values{"username": {username}, "write": {strconv.FormatBool(write)}}.Encode()).
+		EndStruct(&session)
+	if len(errs) > 0 {
+		return nil, errors.New(ConnectionFailedErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return session, nil
+	case http.StatusForbidden:
+		return nil, errors.New(ForbiddenErr)
+	case http.StatusConflict:
+		return nil, errors.New(NotFoundErr)
+	}
+
+	return nil, errors.New(UnknownErr)
+}
+
+func (c *client) GetJob(uid string) (*models.Job, error) {
+	var job *models.Job
+	resp, _, errs := c.http.Get(buildURL(c, fmt.Sprintf("/internal/jobs/%s", uid))).EndStruct(&job)
+	if len(errs) > 0 {
+		return nil, errors.New(ConnectionFailedErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return job, nil
+	case http.StatusNotFound:
+		return nil, errors.New(NotFoundErr)
+	}
+
+	return nil, errors.New(UnknownErr)
+}
+
+func (c *client) SetJobResult(result *models.JobResult) error {
+	resp, _, errs := c.http.Put(buildURL(c, fmt.Sprintf("/internal/jobs/%s/results/%s", result.JobUID, result.DeviceUID))).Send(result).End()
+	if len(errs) > 0 {
+		return errors.New(ConnectionFailedErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return errors.New(NotFoundErr)
+	}
+
+	return errors.New(UnknownErr)
+}
+
+// StartJobResult marks the job as running on the device if it is still in
+// status, failing with ConflictErr when it was already started.
+func (c *client) StartJobResult(job, device, status string) error {
+	resp, _, errs := c.http.Post(buildURL(c, fmt.Sprintf("/internal/jobs/%s/results/%s/start", job, device))).Send(map[string]string{"status": status}).End()
+	if len(errs) > 0 {
+		return errors.New(ConnectionFailedErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return errors.New(ConflictErr)
+	}
+
+	return errors.New(UnknownErr)
+}
+
+// ListWaitingJobResults lists the jobs the device must run once it comes back.
+func (c *client) ListWaitingJobResults(device string) ([]models.JobResult, error) {
+	var results []models.JobResult
+	resp, _, errs := c.http.Get(buildURL(c, fmt.Sprintf("/internal/devices/%s/jobs/waiting", device))).EndStruct(&results)
+	if len(errs) > 0 {
+		return nil, errors.New(ConnectionFailedErr)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(UnknownErr)
+	}
+
+	return results, nil
+}
+
+func (c *client) DeviceOnline(uid string, at time.Time) error {
+	return c.setDevicePresence(uid, "online", at)
+}
+
+func (c *client) DeviceOffline(uid string, at time.Time) error {
+	return c.setDevicePresence(uid, "offline", at)
+}
+
+func (c *client) setDevicePresence(uid, status string, at time.Time) error {
+	resp, _, errs := c.http.Post(buildURL(c, fmt.Sprintf("/internal/devices/%s/%s", uid, status))).Send(map[string]time.Time{"time": at}).End()
+	if len(errs) > 0 {
+		return errors.New(ConnectionFailedErr)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(UnknownErr)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"regexp"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/shellhub-io/shellhub/pkg/models"
 	"github.com/shellhub-io/shellhub/pkg/revdial"
+	"github.com/shellhub-io/shellhub/pkg/tunnelmux"
 	"github.com/shellhub-io/shellhub/pkg/wsconnadapter"
 )
 
@@ -30,7 +32,7 @@ type publicAPI interface {
 	GetInfo() (*models.Info, error)
 	Endpoints() (*models.Endpoints, error)
 	AuthDevice(req *models.DeviceAuthRequest) (*models.DeviceAuthResponse, error)
-	NewReverseListener(token string) (*revdial.Listener, error)
+	NewReverseListener(token string) (net.Listener, error)
 	AuthPublicKey(req *models.PublicKeyAuthRequest, token string) (*models.PublicKeyAuthResponse, error)
 }
 
@@ -64,19 +66,37 @@ func (c *client) Endpoints() (*models.Endpoints, error) {
 	return endpoints, nil
 }
 
-func (c *client) NewReverseListener(token string) (*revdial.Listener, error) {
+// NewReverseListener connects to the gateway and returns a listener for the
+// connections the gateway opens to the device, multiplexed over the same
+// connection when the gateway supports it.
+func (c *client) NewReverseListener(token string) (net.Listener, error) {
 	req, _ := http.NewRequest("GET", "", nil)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set(tunnelmux.TransportHeader, tunnelmux.TransportYamux)
 
 	url := regexp.MustCompile(`^http`).ReplaceAllString(buildURL(c, "/ssh/connection"), "ws")
-	conn, _, err := websocket.DefaultDialer.Dial(url, req.Header)
+	conn, resp, err := c.dialer.Dial(url, req.Header)
 	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, ErrUnauthorized
+		}
+
 		return nil, err
 	}
 
+	if resp.Header.Get(tunnelmux.TransportHeader) == tunnelmux.TransportYamux {
+		listener, err := tunnelmux.NewListener(wsconnadapter.New(conn))
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return listener, nil
+	}
+
 	listener := revdial.NewListener(wsconnadapter.New(conn),
 		func(ctx context.Context, path string) (*websocket.Conn, *http.Response, error) {
-			return tunnelDial(ctx, strings.Replace(c.scheme, "http", "ws", 1), c.host, c.port, path)
+			return tunnelDial(ctx, c.dialer, strings.Replace(c.scheme, "http", "ws", 1), c.host, c.port, path)
 		},
 	)
 
@@ -94,6 +114,6 @@ func (c *client) AuthPublicKey(req *models.PublicKeyAuthRequest, token string) (
 	return res, nil
 }
 
-func tunnelDial(ctx context.Context, protocol, address string, port int, path string) (*websocket.Conn, *http.Response, error) {
-	return websocket.DefaultDialer.DialContext(ctx, strings.Join([]string{fmt.Sprintf("%s://%s:%d", protocol, address, port), path}, ""), nil)
+func tunnelDial(ctx context.Context, dialer *websocket.Dialer, protocol, address string, port int, path string) (*websocket.Conn, *http.Response, error) {
+	return dialer.DialContext(ctx, strings.Join([]string{fmt.Sprintf("%s://%s:%d", protocol, address, port), path}, ""), nil)
 }
@@ -1,10 +1,14 @@
 package client
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
 	"net/url"
 	"strconv"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http/httpproxy"
 )
 
 type Opt func(*client) error
@@ -55,6 +59,54 @@ func WithPort(port int) Opt {
 	}
 }
 
+// WithProxy sets the HTTP proxy used to connect to the server, except for the
+// hosts matched by noProxy (same format as the NO_PROXY environment
+// variable). Basic authentication credentials can be set in the proxy URL.
+// When not set, the proxy is taken from the environment.
+func WithProxy(proxy *url.URL, noProxy string) Opt {
+	return func(c *client) error {
+		cfg := &httpproxy.Config{
+			HTTPProxy:  proxy.String(),
+			HTTPSProxy: proxy.String(),
+			NoProxy:    noProxy,
+		}
+
+		proxyFunc := cfg.ProxyFunc()
+		c.proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+
+		return nil
+	}
+}
+
+// WithRootCAs sets the certificate authorities trusted on TLS connections
+// instead of the system ones.
+func WithRootCAs(pool *x509.CertPool) Opt {
+	return func(c *client) error {
+		c.rootCAs = pool
+		return nil
+	}
+}
+
+// WithClientCertificate sets the certificate presented to the server on TLS
+// connections, for servers requiring mutual TLS authentication.
+func WithClientCertificate(cert tls.Certificate) Opt {
+	return func(c *client) error {
+		c.certificates = []tls.Certificate{cert}
+		return nil
+	}
+}
+
+// WithPublicKeyPins restricts TLS connections to servers whose certificate
+// chain contains one of the given public keys. See ParsePublicKeyPin.
+func WithPublicKeyPins(pins [][]byte) Opt {
+	return func(c *client) error {
+		c.pins = pins
+		return nil
+	}
+}
+
 func WithLogger(logger *logrus.Logger) Opt {
 	return func(c *client) error {
 		c.logger = logger
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+const publicKeyPinPrefix = "sha256//"
+
+var (
+	ErrInvalidPublicKeyPin  = errors.New("invalid public key pin, expected sha256//<base64 hash>")
+	ErrPublicKeyPinMismatch = errors.New("server certificate does not match any pinned public key")
+)
+
+// ParsePublicKeyPin parses a public key pin in the sha256//<base64> format,
+// the base64 encoded SHA-256 hash of the DER encoded SubjectPublicKeyInfo of
+// a certificate in the server chain.
+func ParsePublicKeyPin(pin string) ([]byte, error) {
+	if !strings.HasPrefix(pin, publicKeyPinPrefix) {
+		return nil, ErrInvalidPublicKeyPin
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, publicKeyPinPrefix))
+	if err != nil || len(hash) != sha256.Size {
+		return nil, ErrInvalidPublicKeyPin
+	}
+
+	return hash, nil
+}
+
+func (c *client) tlsConfig() *tls.Config {
+	if c.rootCAs == nil && len(c.certificates) == 0 && len(c.pins) == 0 {
+		return nil
+	}
+
+	cfg := &tls.Config{
+		RootCAs:      c.rootCAs,
+		Certificates: c.certificates,
+	}
+
+	if len(c.pins) > 0 {
+		cfg.VerifyPeerCertificate = verifyPublicKeyPins(c.pins)
+	}
+
+	return cfg
+}
+
+// verifyPublicKeyPins returns a function that checks that the verified chain
+// of the server contains a certificate with one of the pinned public keys.
+func verifyPublicKeyPins(pins [][]byte) func([][]byte, [][]*x509.Certificate) error {
+	return func(_ [][]byte, chains [][]*x509.Certificate) error {
+		for _, chain := range chains {
+			for _, cert := range chain {
+				hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+				for _, pin := range pins {
+					if bytes.Equal(hash[:], pin) {
+						return nil
+					}
+				}
+			}
+		}
+
+		return ErrPublicKeyPinMismatch
+	}
+}
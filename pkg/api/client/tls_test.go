@@ -0,0 +1,81 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePublicKeyPin(t *testing.T) {
+	hash := sha256.Sum256([]byte("key"))
+	encoded := base64.StdEncoding.EncodeToString(hash[:])
+
+	cases := []struct {
+		name string
+		pin  string
+		err  error
+	}{
+		{"valid", "sha256//" + encoded, nil},
+		{"no prefix", encoded, ErrInvalidPublicKeyPin},
+		{"other algorithm", "sha1//" + encoded, ErrInvalidPublicKeyPin},
+		{"invalid base64", "sha256//not base64", ErrInvalidPublicKeyPin},
+		{"short hash", "sha256//" + base64.StdEncoding.EncodeToString(hash[:16]), ErrInvalidPublicKeyPin},
+		{"empty", "", ErrInvalidPublicKeyPin},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pin, err := ParsePublicKeyPin(tc.pin)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, hash[:], pin)
+			}
+		})
+	}
+}
+
+func TestPublicKeyPins(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	cert := server.Certificate()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	match := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	other := sha256.Sum256([]byte("other key"))
+
+	cases := []struct {
+		name string
+		pins [][]byte
+		err  error
+	}{
+		{"match", [][]byte{match[:]}, nil},
+		{"match any", [][]byte{other[:], match[:]}, nil},
+		{"mismatch", [][]byte{other[:]}, ErrPublicKeyPinMismatch},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &client{rootCAs: pool, pins: tc.pins}
+
+			conn, err := tls.Dial("tcp", server.Listener.Addr().String(), c.tlsConfig())
+			if conn != nil {
+				conn.Close()
+			}
+
+			assert.Equal(t, tc.err, err)
+		})
+	}
+
+	// The pins are only checked when set
+	assert.Nil(t, (&client{}).tlsConfig())
+	assert.Nil(t, (&client{rootCAs: pool}).tlsConfig().VerifyPeerCertificate)
+}
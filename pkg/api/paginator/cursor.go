@@ -0,0 +1,42 @@
+package paginator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points to the last item of a page in a list sorted by a stable key
+// (a timestamp plus an unique identifier as tiebreaker). It is handed to the
+// client as an opaque string and allows the next page to be fetched without
+// skipping over the previous ones.
+type Cursor struct {
+	Time time.Time `json:"t"`
+	ID   string    `json:"id"`
+}
+
+// NewCursor returns the opaque representation of a cursor pointing to the
+// item identified by t and id.
+func NewCursor(t time.Time, id string) string {
+	data, _ := json.Marshal(&Cursor{Time: t.UTC(), ID: id})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor decodes an opaque cursor previously returned by NewCursor.
+func ParseCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := new(Cursor)
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
@@ -7,10 +7,15 @@ import (
 type Query struct {
 	Page    int `query:"page"`
 	PerPage int `query:"per_page"`
+
+	// Cursor is an opaque position returned in the X-Next-Cursor header of a
+	// previous response. When set, the list resumes right after that position
+	// and Page is ignored.
+	Cursor string `query:"cursor"`
 }
 
 func NewQuery() *Query {
-	return &Query{1, 25}
+	return &Query{Page: 1, PerPage: 25}
 }
 
 func (q *Query) Normalize() {
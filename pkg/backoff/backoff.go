@@ -0,0 +1,79 @@
+// Package backoff computes retry delays that grow exponentially up to a cap,
+// with full jitter so that clients failing at the same time, e.g. when the
+// server restarts, spread their retries instead of retrying in lockstep.
+package backoff
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const DefaultFactor = 2
+
+var (
+	// The global math/rand source is seeded the same way on every process,
+	// so it would give every device the same sequence of delays
+	random   = rand.New(rand.NewSource(seed())) // nolint:gosec
+	randomMu sync.Mutex
+)
+
+func seed() int64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+// Duration returns a random delay between zero and min*factor^attempt,
+// capped at max.
+func Duration(min, max time.Duration, factor float64, attempt int) time.Duration {
+	d := float64(min) * math.Pow(factor, float64(attempt))
+	if d > float64(max) || math.IsInf(d, 0) || math.IsNaN(d) {
+		d = float64(max)
+	}
+
+	randomMu.Lock()
+	defer randomMu.Unlock()
+
+	return time.Duration(random.Int63n(int64(d) + 1))
+}
+
+// Backoff keeps track of the attempts of an operation being retried.
+type Backoff struct {
+	Min    time.Duration
+	Max    time.Duration
+	Factor float64
+
+	attempt int
+}
+
+func New(min, max time.Duration) *Backoff {
+	return &Backoff{
+		Min:    min,
+		Max:    max,
+		Factor: DefaultFactor,
+	}
+}
+
+// Next returns how long to wait before the next attempt.
+func (b *Backoff) Next() time.Duration {
+	d := Duration(b.Min, b.Max, b.Factor, b.attempt)
+
+	// Stop counting once the cap is reached to avoid overflowing
+	if float64(b.Min)*math.Pow(b.Factor, float64(b.attempt)) < float64(b.Max) {
+		b.attempt++
+	}
+
+	return d
+}
+
+// Reset restarts the delays from Min, e.g. after a successful attempt.
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
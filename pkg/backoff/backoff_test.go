@@ -0,0 +1,57 @@
+package backoff
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuration(t *testing.T) {
+	cases := []struct {
+		name    string
+		attempt int
+		max     time.Duration
+	}{
+		{"first attempt", 0, time.Second},
+		{"grows", 3, 8 * time.Second},
+		{"capped", 10, time.Minute},
+		{"overflow", math.MaxInt32, time.Minute},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				d := Duration(time.Second, time.Minute, DefaultFactor, tc.attempt)
+				assert.True(t, d >= 0 && d <= tc.max, "%s out of [0, %s]", d, tc.max)
+			}
+		})
+	}
+}
+
+func TestDurationJitter(t *testing.T) {
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		seen[Duration(time.Second, time.Minute, DefaultFactor, 5)] = true
+	}
+
+	// Retries spread instead of all waiting the same delay
+	assert.True(t, len(seen) > 1)
+}
+
+func TestBackoff(t *testing.T) {
+	b := New(time.Second, 4*time.Second)
+
+	for _, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		d := b.Next()
+		assert.True(t, d >= 0 && d <= max, "%s out of [0, %s]", d, max)
+	}
+
+	// Attempts stop growing once the cap is reached
+	assert.Equal(t, 2, b.attempt)
+
+	b.Reset()
+	assert.Equal(t, 0, b.attempt)
+	assert.True(t, b.Next() <= time.Second)
+}
@@ -11,32 +11,55 @@ import (
 
 var ErrNoConnection = errors.New("no connection")
 
-type ConnectionManager struct {
-	dialers map[string]*revdial.Dialer
-	lock    sync.RWMutex
-	status  chan TunnelStatus
+// Dialer creates new connections to the other side of a tunnel.
+type Dialer interface {
+	Dial(ctx context.Context) (net.Conn, error)
+	Done() <-chan struct{}
+	Close() error
 }
 
-type TunnelStatus struct {
-	id     string
-	online bool
+type ConnectionManager struct {
+	dialers map[string]Dialer
+	lock    sync.RWMutex
+	// DialerDoneCallback is called when the connection of key is closed.
+	// It is not called for connections replaced by a newer one.
+	DialerDoneCallback func(key string)
 }
 
 func New() *ConnectionManager {
 	return &ConnectionManager{
-		dialers: make(map[string]*revdial.Dialer),
-		status:  make(chan TunnelStatus),
+		dialers:            make(map[string]Dialer),
+		DialerDoneCallback: func(string) {},
 	}
 }
 
-func (m *ConnectionManager) Set(key string, conn net.Conn) {
+// Set registers the connection of key replacing any previous one. The
+// dialerPath is where the other side of the connection picks up new
+// connections.
+func (m *ConnectionManager) Set(key string, conn net.Conn, dialerPath string) {
+	m.SetDialer(key, revdial.NewDialer(conn, dialerPath))
+}
+
+// SetDialer registers the dialer of key replacing any previous one.
+func (m *ConnectionManager) SetDialer(key string, dialer Dialer) {
 	m.lock.Lock()
-	m.dialers[key] = revdial.NewDialer(conn, "/ssh/revdial")
+	m.dialers[key] = dialer
 	m.lock.Unlock()
 
 	go func() {
-		stats := TunnelStatus{id: key, online: m.dialers[key].IsOnline()}
-		m.status <- stats
+		<-dialer.Done()
+
+		m.lock.Lock()
+		// Ignore a dialer already replaced by a new connection of the same key
+		if m.dialers[key] != dialer {
+			m.lock.Unlock()
+			return
+		}
+
+		delete(m.dialers, key)
+		m.lock.Unlock()
+
+		m.DialerDoneCallback(key)
 	}()
 }
 
@@ -52,9 +75,23 @@ func (m *ConnectionManager) Dial(ctx context.Context, key string) (net.Conn, err
 	return dialer.Dial(ctx)
 }
 
-func (m *ConnectionManager) Online() (id string, online bool) {
-	status := <-m.status
-	id = status.id
-	online = status.online
-	return
+// Keys returns the keys of all connections currently held.
+func (m *ConnectionManager) Keys() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	keys := make([]string, 0, len(m.dialers))
+	for key := range m.dialers {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Len returns the number of connections currently held.
+func (m *ConnectionManager) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return len(m.dialers)
 }
@@ -0,0 +1,207 @@
+package httptunnel
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shellhub-io/shellhub/pkg/connman"
+)
+
+const forwardProtocol = "shellhub-tunnel"
+
+const (
+	// instanceParam and signatureParam carry the instance holding the tunnel
+	// in the pickup path, signed with the secret of the instances.
+	instanceParam  = "gateway"
+	signatureParam = "gateway_sig"
+)
+
+// InstanceHeader carries the instance a pickup must be routed to in the
+// response of the verify handler.
+const InstanceHeader = "X-Gateway-Instance"
+
+var ErrForwardFailed = errors.New("failed to forward connection")
+
+// signInstance returns the signature of the address of an instance.
+func (t *Tunnel) signInstance(instance string) string {
+	mac := hmac.New(sha256.New, []byte(t.Secret))
+	mac.Write([]byte(instance)) // nolint:errcheck
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (t *Tunnel) verifyInstance(instance, signature string) bool {
+	if t.Secret == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(t.signInstance(instance)), []byte(signature))
+}
+
+// pickupInstance returns the instance of a pickup from its query, if any,
+// reporting whether it's signed. The gateway matches the query arguments
+// regardless of their case, so any variant of the instance parameters other
+// than a single signed instance is rejected.
+func (t *Tunnel) pickupInstance(query url.Values) (string, bool) {
+	found := false
+	for key, values := range query {
+		if !strings.EqualFold(key, instanceParam) && !strings.EqualFold(key, signatureParam) {
+			continue
+		}
+
+		if (key != instanceParam && key != signatureParam) || len(values) != 1 {
+			return "", false
+		}
+
+		found = true
+	}
+
+	if !found {
+		return "", true
+	}
+
+	instance := query.Get(instanceParam)
+	if instance == "" || !t.verifyInstance(instance, query.Get(signatureParam)) {
+		return "", false
+	}
+
+	return instance, true
+}
+
+// authorized reports whether the request comes from other instance, holding
+// the same secret.
+func (t *Tunnel) authorized(req *http.Request) bool {
+	if t.Secret == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte("Bearer "+t.Secret)) == 1
+}
+
+// serveForward dials to a device connected to this instance and splices the
+// connection with the hijacked connection of the request, allowing other
+// instances to reach the device.
+func (t *Tunnel) serveForward(res http.ResponseWriter, req *http.Request, id string) {
+	if !t.authorized(req) {
+		http.Error(res, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !strings.EqualFold(req.Header.Get("Upgrade"), forwardProtocol) {
+		http.Error(res, "unsupported upgrade", http.StatusBadRequest)
+		return
+	}
+
+	// Never use the fallback here to avoid forwarding loops between instances
+	conn, err := t.connman.Dial(req.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == connman.ErrNoConnection {
+			status = http.StatusNotFound
+		}
+
+		http.Error(res, err.Error(), status)
+		return
+	}
+
+	hijacker, ok := res.(http.Hijacker)
+	if !ok {
+		conn.Close()
+		http.Error(res, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\nConnection: Upgrade\r\nUpgrade: %s\r\n\r\n", // nolint:errcheck
+		http.StatusSwitchingProtocols, http.StatusText(http.StatusSwitchingProtocols), forwardProtocol)
+	if err := buf.Flush(); err != nil {
+		client.Close()
+		conn.Close()
+		return
+	}
+
+	go func() {
+		io.Copy(conn, buf) // nolint:errcheck
+		conn.Close()
+	}()
+
+	io.Copy(client, conn) // nolint:errcheck
+	client.Close()
+}
+
+// DialForward dials to the device identified by id through the tunnel of the
+// instance reachable at addr, which must serve the forward handler at the
+// DefaultForwardURL and share the secret.
+func DialForward(ctx context.Context, addr, id, secret string) (net.Conn, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bound the handshake by the context deadline, if any
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)          // nolint:errcheck
+		defer conn.SetDeadline(time.Time{}) // nolint:errcheck
+	}
+
+	path := strings.Replace(DefaultForwardURL, "{id}", id, 1)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+addr+path, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", forwardProtocol)
+	req.Header.Set("Authorization", "Bearer "+secret)
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, connman.ErrNoConnection
+		}
+
+		return nil, ErrForwardFailed
+	}
+
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn reads from the reader used to parse the upgrade response,
+// which may already hold data sent by the device.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,83 @@
+package httptunnel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/shellhub-io/shellhub/pkg/connman"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyInstance(t *testing.T) {
+	tunnel := NewTunnel("/connection", "/revdial")
+	tunnel.Instance = "10.0.0.1:8080"
+	tunnel.Secret = "secret"
+
+	server := httptest.NewServer(tunnel.Router())
+	defer server.Close()
+
+	pickup, err := url.Parse(tunnel.pickupPath())
+	assert.NoError(t, err)
+
+	signature := pickup.Query().Get(signatureParam)
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	cases := []struct {
+		name     string
+		query    url.Values
+		status   int
+		instance string
+	}{
+		{"signed", pickup.Query(), http.StatusOK, "10.0.0.1:8080"},
+		{"no instance", url.Values{}, http.StatusOK, host},
+		{"other instance", url.Values{instanceParam: {"10.0.0.2:8080"}, signatureParam: {signature}}, http.StatusForbidden, ""},
+		{"unsigned", url.Values{instanceParam: {"10.0.0.1:8080"}}, http.StatusForbidden, ""},
+		{"empty instance", url.Values{instanceParam: {""}, signatureParam: {signature}}, http.StatusForbidden, ""},
+		{"upper case", url.Values{"GATEWAY": {"api:8080"}}, http.StatusForbidden, ""},
+		{"upper case signed", url.Values{"Gateway": {"10.0.0.1:8080"}, signatureParam: {signature}}, http.StatusForbidden, ""},
+		{"upper case along signed", url.Values{instanceParam: {"10.0.0.1:8080"}, signatureParam: {signature}, "GATEWAY": {"api:8080"}}, http.StatusForbidden, ""},
+		{"many instances", url.Values{instanceParam: {"10.0.0.1:8080", "api:8080"}, signatureParam: {signature}}, http.StatusForbidden, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + DefaultVerifyURL + "?" + tc.query.Encode())
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Equal(t, tc.instance, resp.Header.Get(InstanceHeader))
+		})
+	}
+
+	other := NewTunnel("/connection", "/revdial")
+	other.Secret = "other"
+	assert.False(t, other.verifyInstance("10.0.0.1:8080", pickup.Query().Get(signatureParam)))
+
+	other.Secret = ""
+	assert.False(t, other.verifyInstance("10.0.0.1:8080", other.signInstance("10.0.0.1:8080")))
+}
+
+func TestDialForward(t *testing.T) {
+	tunnel := NewTunnel("/connection", "/revdial")
+	tunnel.Secret = "secret"
+
+	server := httptest.NewServer(tunnel.Router())
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	// The device is not connected, so the instance accepting the secret
+	// answers it does not hold its tunnel
+	_, err := DialForward(context.Background(), addr, "device", "secret")
+	assert.Equal(t, connman.ErrNoConnection, err)
+
+	_, err = DialForward(context.Background(), addr, "device", "wrong")
+	assert.Equal(t, ErrForwardFailed, err)
+
+	_, err = DialForward(context.Background(), addr, "device", "")
+	assert.Equal(t, ErrForwardFailed, err)
+}
@@ -6,11 +6,14 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/shellhub-io/shellhub/pkg/connman"
 	"github.com/shellhub-io/shellhub/pkg/revdial"
+	"github.com/shellhub-io/shellhub/pkg/tunnelmux"
 	"github.com/shellhub-io/shellhub/pkg/wsconnadapter"
 )
 
@@ -28,35 +31,59 @@ var (
 const (
 	DefaultConnectionURL = "/connection"
 	DefaultRevdialURL    = "/revdial"
+	DefaultForwardURL    = "/tunnels/{id}/dial"
+	DefaultVerifyURL     = "/tunnels/verify"
 )
 
 type Tunnel struct {
 	ConnectionPath    string
 	DialerPath        string
+	ForwardPath       string
 	ConnectionHandler func(*http.Request) (string, error)
-	connman           *connman.ConnectionManager
-	id                chan string
-	online            chan bool
+	// CloseHandler is called when the connection of a device is closed.
+	CloseHandler func(id string)
+	// Instance is the address (host:port) where this tunnel can be reached by
+	// other instances. When set, it is sent along with the dialer path so the
+	// connections picked up by the device can be routed back to this instance.
+	Instance string
+	// Secret is shared by the instances to authenticate the connections
+	// forwarded between them and to sign the instance sent to the devices.
+	Secret string
+	// DialFallback is called to dial to devices that are not connected to
+	// this instance, e.g. to forward the connection through other instance.
+	DialFallback func(ctx context.Context, id string) (net.Conn, error)
+	connman      *connman.ConnectionManager
 }
 
 func NewTunnel(connectionPath, dialerPath string) *Tunnel {
 	return &Tunnel{
 		ConnectionPath: connectionPath,
 		DialerPath:     dialerPath,
+		ForwardPath:    DefaultForwardURL,
 		ConnectionHandler: func(r *http.Request) (string, error) {
 			panic("ConnectionHandler not implemented")
 		},
-		connman: connman.New(),
-		id:      make(chan string),
-		online:  make(chan bool),
+		CloseHandler: func(id string) {},
+		connman:      connman.New(),
 	}
 }
 
 func (t *Tunnel) Router() http.Handler {
 	router := mux.NewRouter()
 
+	t.connman.DialerDoneCallback = func(id string) {
+		t.CloseHandler(id)
+	}
+
 	router.HandleFunc(t.ConnectionPath, func(res http.ResponseWriter, req *http.Request) {
-		conn, err := upgrader.Upgrade(res, req, nil)
+		transport := negotiateTransport(req)
+
+		header := http.Header{}
+		if transport != "" {
+			header.Set(tunnelmux.TransportHeader, transport)
+		}
+
+		conn, err := upgrader.Upgrade(res, req, header)
 		if err != nil {
 			http.Error(res, err.Error(), http.StatusInternalServerError)
 			return
@@ -69,36 +96,97 @@ func (t *Tunnel) Router() http.Handler {
 			return
 		}
 
-		t.connman.Set(id, wsconnadapter.New(conn))
+		switch transport {
+		case tunnelmux.TransportYamux:
+			dialer, err := tunnelmux.NewDialer(wsconnadapter.New(conn))
+			if err != nil {
+				conn.Close()
+				return
+			}
+
+			t.connman.SetDialer(id, dialer)
+		default:
+			t.connman.Set(id, wsconnadapter.New(conn), t.pickupPath())
+		}
 	}).Methods(http.MethodGet)
 
 	router.Handle(t.DialerPath, revdial.ConnHandler(upgrader)).Methods(http.MethodGet)
 
+	router.HandleFunc(t.ForwardPath, func(res http.ResponseWriter, req *http.Request) {
+		t.serveForward(res, req, mux.Vars(req)["id"])
+	}).Methods(http.MethodGet)
+
+	// The gateway in front of the instances checks the instance of the
+	// pickups here before routing them to it
+	router.HandleFunc(DefaultVerifyURL, func(res http.ResponseWriter, req *http.Request) {
+		instance, ok := t.pickupInstance(req.URL.Query())
+		if !ok {
+			res.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		// Pickups not bound to an instance keep going to the upstream the
+		// gateway reached this instance at
+		if instance == "" {
+			instance = req.Host
+		}
+
+		res.Header().Set(InstanceHeader, instance)
+		res.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+
 	return router
 }
 
+// Dial dials to the device identified by id, falling back to DialFallback
+// when the device is not connected to this instance.
 func (t *Tunnel) Dial(ctx context.Context, id string) (net.Conn, error) {
-	return t.connman.Dial(ctx, id)
+	conn, err := t.connman.Dial(ctx, id)
+	if err == connman.ErrNoConnection && t.DialFallback != nil {
+		return t.DialFallback(ctx, id)
+	}
+
+	return conn, err
 }
 
+// SendRequest sends req to the device identified by id over a new connection,
+// which is closed along with the body of the response.
 func (t *Tunnel) SendRequest(ctx context.Context, id string, req *http.Request) (*http.Response, error) {
-	conn, err := t.connman.Dial(ctx, id)
+	conn, err := t.Dial(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := req.Write(conn); err != nil {
+		conn.Close()
 		return nil, err
 	}
 
 	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
 
+	resp.Body = &connBody{ReadCloser: resp.Body, conn: conn}
+
 	return resp, nil
 }
 
+// connBody closes the connection the response was read from when the body is
+// closed.
+type connBody struct {
+	io.ReadCloser
+	conn net.Conn
+}
+
+func (b *connBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.conn.Close()
+
+	return err
+}
+
 func (t *Tunnel) ForwardResponse(resp *http.Response, w http.ResponseWriter) {
 	for key, values := range resp.Header {
 		for _, value := range values {
@@ -111,7 +199,35 @@ func (t *Tunnel) ForwardResponse(resp *http.Response, w http.ResponseWriter) {
 	resp.Body.Close()
 }
 
-func (t *Tunnel) Online() (id string, online bool) {
-	id, online = t.connman.Online()
-	return
+// negotiateTransport picks the tunnel transport among the ones supported by
+// the device, returning empty for the revdial transport.
+func negotiateTransport(req *http.Request) string {
+	for _, transport := range strings.Split(req.Header.Get(tunnelmux.TransportHeader), ",") {
+		if strings.TrimSpace(transport) == tunnelmux.TransportYamux {
+			return tunnelmux.TransportYamux
+		}
+	}
+
+	return ""
+}
+
+func (t *Tunnel) pickupPath() string {
+	if t.Instance == "" {
+		return t.DialerPath
+	}
+
+	return t.DialerPath + "?" + url.Values{
+		instanceParam:  {t.Instance},
+		signatureParam: {t.signInstance(t.Instance)},
+	}.Encode()
+}
+
+// Connected returns the ids of the devices connected to this instance.
+func (t *Tunnel) Connected() []string {
+	return t.connman.Keys()
+}
+
+// Len returns the number of devices connected to this instance.
+func (t *Tunnel) Len() int {
+	return t.connman.Len()
 }
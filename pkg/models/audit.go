@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// AuditEvent records an administrative action performed by an actor (usually
+// an user) on a target within a namespace.
+type AuditEvent struct {
+	Time     time.Time   `json:"time" bson:"time"`
+	TenantID string      `json:"tenant_id" bson:"tenant_id"`
+	Actor    string      `json:"actor" bson:"actor"`
+	Action   string      `json:"action" bson:"action"`
+	Target   string      `json:"target" bson:"target"`
+	Before   interface{} `json:"before,omitempty" bson:"before,omitempty"`
+	After    interface{} `json:"after,omitempty" bson:"after,omitempty"`
+}
@@ -14,9 +14,15 @@ type Device struct {
 	PublicKey string          `json:"public_key" bson:"public_key"`
 	TenantID  string          `json:"tenant_id" bson:"tenant_id"`
 	LastSeen  time.Time       `json:"last_seen" bson:"last_seen"`
+	CreatedAt time.Time       `json:"created_at" bson:"created_at,omitempty"`
 	Online    bool            `json:"online" bson:",omitempty"`
 	Namespace string          `json:"namespace" bson:",omitempty"`
 	Status    string          `json:"status" bson:"status,omitempty" validate:"oneof=accepted rejected pending unused`
+
+	LastConnected    time.Time `json:"last_connected" bson:"last_connected,omitempty"`
+	LastDisconnected time.Time `json:"last_disconnected" bson:"last_disconnected,omitempty"`
+
+	Update *UpdatePolicy `json:"update,omitempty" bson:"update,omitempty"`
 }
 
 type DeviceAuthClaims struct {
@@ -44,6 +50,10 @@ type DeviceAuthResponse struct {
 	Token     string `json:"token"`
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
+
+	// Version the agent must be updated to, empty when the server does
+	// not assign one
+	UpdateVersion string `json:"update_version,omitempty"`
 }
 
 type DeviceIdentity struct {
@@ -56,6 +66,24 @@ type DeviceInfo struct {
 	Version    string `json:"version"`
 	Arch       string `json:"arch"`
 	Platform   string `json:"platform"`
+
+	// Inventory of the host, sizes are in bytes and uptime in seconds
+	Hostname        string            `json:"hostname,omitempty" bson:"hostname,omitempty"`
+	KernelVersion   string            `json:"kernel_version,omitempty" bson:"kernel_version,omitempty"`
+	CPUModel        string            `json:"cpu_model,omitempty" bson:"cpu_model,omitempty"`
+	CPUCount        int               `json:"cpu_count,omitempty" bson:"cpu_count,omitempty"`
+	MemoryTotal     int64             `json:"memory_total,omitempty" bson:"memory_total,omitempty"`
+	MemoryAvailable int64             `json:"memory_available,omitempty" bson:"memory_available,omitempty"`
+	DiskTotal       int64             `json:"disk_total,omitempty" bson:"disk_total,omitempty"`
+	DiskFree        int64             `json:"disk_free,omitempty" bson:"disk_free,omitempty"`
+	Uptime          int64             `json:"uptime,omitempty" bson:"uptime,omitempty"`
+	Interfaces      []DeviceInterface `json:"interfaces,omitempty" bson:"interfaces,omitempty"`
+}
+
+// DeviceInterface is a network interface of the device and its IP addresses.
+type DeviceInterface struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses"`
 }
 
 type ConnectedDevice struct {
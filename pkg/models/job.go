@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+const (
+	JobStatusRunning  = "running"
+	JobStatusFinished = "finished"
+)
+
+const (
+	// JobResultPending is the status of the devices online when the job is
+	// created, which are about to run it.
+	JobResultPending = "pending"
+	// JobResultWaiting is the status of the devices offline when the job is
+	// created, which run it when they come back.
+	JobResultWaiting = "waiting"
+	JobResultRunning = "running"
+	JobResultSuccess = "success"
+	JobResultFailed  = "failed"
+	// JobResultSkipped is the status of the devices offline when a job not
+	// retried is created.
+	JobResultSkipped = "skipped"
+)
+
+// Job is a command run on the devices of a namespace matching a filter.
+type Job struct {
+	UID        string     `json:"uid" bson:"uid"`
+	TenantID   string     `json:"tenant_id" bson:"tenant_id"`
+	Command    string     `json:"command" bson:"command"`
+	User       string     `json:"user" bson:"user"`
+	Filter     string     `json:"filter,omitempty" bson:"filter,omitempty"`
+	Retry      bool       `json:"retry" bson:"retry"`
+	Timeout    int        `json:"timeout" bson:"timeout"`
+	Status     string     `json:"status" bson:"status"`
+	CreatedBy  string     `json:"created_by" bson:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+// JobResult is the outcome of a job on a device. The duration is given in
+// milliseconds.
+type JobResult struct {
+	JobUID    string     `json:"job_uid" bson:"job_uid"`
+	DeviceUID string     `json:"device_uid" bson:"device_uid"`
+	TenantID  string     `json:"tenant_id" bson:"tenant_id"`
+	Status    string     `json:"status" bson:"status"`
+	ExitCode  *int       `json:"exit_code,omitempty" bson:"exit_code,omitempty"`
+	Stdout    string     `json:"stdout" bson:"stdout"`
+	Stderr    string     `json:"stderr" bson:"stderr"`
+	Truncated bool       `json:"truncated,omitempty" bson:"truncated,omitempty"`
+	Error     string     `json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	Duration  int64      `json:"duration" bson:"duration"`
+}
+
+// Finished reports whether the result is final.
+func (r *JobResult) Finished() bool {
+	switch r.Status {
+	case JobResultSuccess, JobResultFailed, JobResultSkipped:
+		return true
+	}
+
+	return false
+}
+
+// JobDispatch is sent to the gateway to run a job on the devices.
+type JobDispatch struct {
+	Job     Job      `json:"job"`
+	Devices []string `json:"devices"`
+}
@@ -2,7 +2,47 @@ package models
 
 import "time"
 
+// Features enabled by a license
+const (
+	// FeatureFirewall evaluates the firewall rules of the namespace before
+	// connecting to its devices.
+	FeatureFirewall = "firewall"
+)
+
 type License struct {
 	RawData   []byte
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 }
+
+// LicenseInfo is the signed content of a license.
+type LicenseInfo struct {
+	ID       string   `json:"id"`
+	Customer string   `json:"customer"`
+	Features []string `json:"features"`
+	// MaxDevices is the number of devices of each namespace created while the
+	// license is valid, being unlimited when zero.
+	MaxDevices int       `json:"max_devices"`
+	IssuedAt   time.Time `json:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the license is past its expiration at t.
+func (l *LicenseInfo) Expired(t time.Time) bool {
+	return !l.ExpiresAt.IsZero() && t.After(l.ExpiresAt)
+}
+
+// HasFeature reports whether the license enables feature.
+func (l *LicenseInfo) HasFeature(feature string) bool {
+	for _, f := range l.Features {
+		if f == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Features are the features enabled on the server.
+type Features struct {
+	Features []string `json:"features"`
+}
@@ -16,10 +16,12 @@ type MqttClientEvent struct {
 	Username string `json:"username"`
 }
 
+// MqttAuthQuery is sent in the body of the request, keeping the token of the
+// device out of the access logs.
 type MqttAuthQuery struct {
-	Username string `query:"username"`
-	Password string `query:"password"`
-	IPAddr   string `query:"ipaddr"`
+	Username string `form:"username" json:"username"`
+	Password string `form:"password" json:"password"`
+	IPAddr   string `form:"ipaddr" json:"ipaddr"`
 }
 
 type MqttACLQuery struct {
@@ -12,8 +12,24 @@ type Namespace struct {
 	DevicesCount int                `json:"devices_count" bson:"devices_count,omitempty"`
 }
 
+// HasMaxDevicesReached reports whether the namespace already has as many
+// accepted devices as it is allowed to. A negative MaxDevices means there is
+// no limit.
+func (n *Namespace) HasMaxDevicesReached() bool {
+	return n.MaxDevices >= 0 && n.DevicesCount >= n.MaxDevices
+}
+
+// NamespaceUsage is the number of devices of a namespace against its limit.
+type NamespaceUsage struct {
+	Devices        int `json:"devices"`
+	PendingDevices int `json:"pending_devices"`
+	MaxDevices     int `json:"max_devices"`
+}
+
 type NamespaceSettings struct {
-	SessionRecord bool `json:"session_record" bson:"session_record,omitempty"`
+	SessionRecord bool          `json:"session_record" bson:"session_record,omitempty"`
+	ShadowWrite   bool          `json:"shadow_write" bson:"shadow_write,omitempty"`
+	Update        *UpdatePolicy `json:"update,omitempty" bson:"update,omitempty"`
 }
 
 type Member struct {
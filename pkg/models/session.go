@@ -16,6 +16,8 @@ type Session struct {
 	Active        bool      `json:"active" bson:",omitempty"`
 	Authenticated bool      `json:"authenticated" bson:"authenticated"`
 	Recorded      bool      `json:"recorded" bson:"recorded"`
+	Reason        string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	Instance      string    `json:"instance,omitempty" bson:"instance,omitempty"`
 }
 
 type ActiveSession struct {
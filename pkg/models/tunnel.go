@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Tunnel records which gateway instance holds the reverse tunnel of a device.
+type Tunnel struct {
+	UID       string    `json:"uid"`
+	Instance  string    `json:"instance"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// UpdateChannel is a release track of the agent. Only the percentage of the
+// devices given by Rollout is updated to Version, the others are kept on
+// PreviousVersion.
+type UpdateChannel struct {
+	Name            string    `json:"name" bson:"name" validate:"required,alphanum"`
+	Version         string    `json:"version" bson:"version" validate:"required"`
+	PreviousVersion string    `json:"previous_version,omitempty" bson:"previous_version,omitempty"`
+	Rollout         int       `json:"rollout" bson:"rollout" validate:"min=0,max=100"`
+	UpdatedAt       time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// UpdatePolicy selects the agent version of a namespace or device, either
+// following a channel or pinned to a version.
+type UpdatePolicy struct {
+	Channel string `json:"channel,omitempty" bson:"channel,omitempty"`
+	Version string `json:"version,omitempty" bson:"version,omitempty"`
+}
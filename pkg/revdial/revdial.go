@@ -29,6 +29,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -39,6 +40,15 @@ import (
 // containing the Dialer's random unique ID.
 const dialerUniqParam = "revdial.dialer"
 
+const (
+	// keepAliveInterval is how often the Dialer sends keep-alive messages.
+	keepAliveInterval = 30 * time.Second
+	// heartbeatTimeout is how long the Dialer waits for a keep-alive reply
+	// before considering the peer gone. Peers that never replied (older
+	// listeners) are only detected by write errors.
+	heartbeatTimeout = 3 * keepAliveInterval
+)
+
 // The Dialer can create new connections.
 type Dialer struct {
 	conn       net.Conn // hijacked client conn
@@ -51,7 +61,8 @@ type Dialer struct {
 	connReady    chan bool
 	donec        chan struct{}
 	closeOnce    sync.Once
-	online       chan bool
+
+	lastHeartbeat int64 // unix nanoseconds of the last keep-alive reply; accessed atomically
 }
 
 var (
@@ -74,7 +85,6 @@ func NewDialer(c net.Conn, connPath string) *Dialer {
 		connReady:    make(chan bool),
 		incomingConn: make(chan net.Conn),
 		pickupFailed: make(chan error),
-		online:       make(chan bool),
 	}
 
 	join := "?"
@@ -112,7 +122,6 @@ func (d *Dialer) Done() <-chan struct{} { return d.donec }
 
 // Close closes the Dialer.
 func (d *Dialer) Close() error {
-	d.online <- false
 	d.closeOnce.Do(d.close)
 	return nil
 }
@@ -172,6 +181,8 @@ func (d *Dialer) serve() error {
 				return
 			}
 			switch msg.Command {
+			case "keep-alive":
+				atomic.StoreInt64(&d.lastHeartbeat, time.Now().UnixNano())
 			case "pickup-failed":
 				err := fmt.Errorf("revdial listener failed to pick up connection: %v", msg.Err)
 				select {
@@ -183,11 +194,15 @@ func (d *Dialer) serve() error {
 		}
 	}()
 	for {
+		if last, ok := d.LastHeartbeat(); ok && time.Since(last) > heartbeatTimeout {
+			return errors.New("revdial.Dialer heartbeat timeout")
+		}
+
 		if err := d.sendMessage(controlMsg{Command: "keep-alive"}); err != nil {
 			return err
 		}
 
-		t := time.NewTimer(30 * time.Second)
+		t := time.NewTimer(keepAliveInterval)
 		select {
 		case <-t.C:
 			continue
@@ -301,8 +316,10 @@ func (ln *Listener) run() {
 		}
 		switch msg.Command {
 		case "keep-alive":
-			// Occasional no-op message from server to keep
-			// us alive through NAT timeouts.
+			// Occasional message from server to keep us alive
+			// through NAT timeouts. Reply so the server knows
+			// we are still here.
+			ln.sendMessage(controlMsg{Command: "keep-alive"})
 		case "conn-ready":
 			go ln.grabConn(msg.ConnPath)
 		default:
@@ -314,7 +331,10 @@ func (ln *Listener) run() {
 func (ln *Listener) sendMessage(m controlMsg) {
 	j, _ := json.Marshal(m)
 	j = append(j, '\n')
-	ln.writec <- j
+	select {
+	case ln.writec <- j:
+	case <-ln.donec:
+	}
 }
 
 func (ln *Listener) grabConn(path string) {
@@ -419,6 +439,13 @@ func ConnHandler(upgrader websocket.Upgrader) http.Handler {
 	})
 }
 
-func (d *Dialer) IsOnline() bool {
-	return <-d.online
+// LastHeartbeat returns the time of the last keep-alive reply received from
+// the peer. It reports false if the peer has never replied.
+func (d *Dialer) LastHeartbeat() (time.Time, bool) {
+	last := atomic.LoadInt64(&d.lastHeartbeat)
+	if last == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, last), true
 }
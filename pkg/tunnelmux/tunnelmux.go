@@ -0,0 +1,98 @@
+// Package tunnelmux implements a tunnel transport that multiplexes many
+// streams over the single connection the device opens to the gateway, instead
+// of picking up a new connection for every dial as revdial does.
+//
+// The transport is negotiated on the connection request: the device sends the
+// TransportHeader with the transports it supports and the gateway answers with
+// the one chosen. Devices that do not get the header back fall back to revdial.
+package tunnelmux
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/hashicorp/yamux"
+)
+
+const (
+	// TransportHeader is the HTTP header used to negotiate the tunnel transport.
+	TransportHeader = "X-Tunnel-Transport"
+	// TransportYamux multiplexes streams using yamux framing.
+	TransportYamux = "yamux"
+)
+
+func config() *yamux.Config {
+	cfg := yamux.DefaultConfig()
+	cfg.KeepAliveInterval = 30 * time.Second
+	cfg.LogOutput = ioutil.Discard
+
+	return cfg
+}
+
+// Dialer opens new streams to the device through the multiplexed connection.
+type Dialer struct {
+	session *yamux.Session
+}
+
+// NewDialer returns the gateway side of a multiplexed connection.
+func NewDialer(conn net.Conn) (*Dialer, error) {
+	session, err := yamux.Client(conn, config())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Dialer{session: session}, nil
+}
+
+// Dial opens a new stream to the device.
+func (d *Dialer) Dial(ctx context.Context) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+
+	resc := make(chan result, 1)
+	go func() {
+		// Never wrap a nil stream in the interface, so failed opens have no
+		// connection to close
+		stream, err := d.session.OpenStream()
+		if err != nil {
+			resc <- result{nil, err}
+			return
+		}
+
+		resc <- result{stream, nil}
+	}()
+
+	select {
+	case res := <-resc:
+		return res.conn, res.err
+	case <-ctx.Done():
+		go func() {
+			if res := <-resc; res.conn != nil {
+				res.conn.Close()
+			}
+		}()
+
+		return nil, ctx.Err()
+	}
+}
+
+// Done returns a channel which is closed when the connection is closed,
+// including when the device stops answering keep-alives.
+func (d *Dialer) Done() <-chan struct{} {
+	return d.session.CloseChan()
+}
+
+// Close closes the multiplexed connection and all its streams.
+func (d *Dialer) Close() error {
+	return d.session.Close()
+}
+
+// NewListener returns the device side of a multiplexed connection, accepting
+// the streams opened by the gateway.
+func NewListener(conn net.Conn) (net.Listener, error) {
+	return yamux.Server(conn, config())
+}
@@ -0,0 +1,78 @@
+package tunnelmux
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialAccept(t *testing.T) {
+	gateway, device := net.Pipe()
+
+	dialer, err := NewDialer(gateway)
+	assert.NoError(t, err)
+	defer dialer.Close()
+
+	listener, err := NewListener(device)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	// Echo every stream accepted by the device
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				io.Copy(conn, conn) // nolint:errcheck
+				conn.Close()
+			}()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Many streams share the same connection
+	for _, msg := range []string{"first", "second"} {
+		conn, err := dialer.Dial(ctx)
+		assert.NoError(t, err)
+
+		_, err = conn.Write([]byte(msg))
+		assert.NoError(t, err)
+
+		buf := make([]byte, len(msg))
+		_, err = io.ReadFull(conn, buf)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, string(buf))
+
+		assert.NoError(t, conn.Close())
+	}
+
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+
+	// The stream may still open before the cancellation is seen
+	if conn, err := dialer.Dial(canceled); err == nil {
+		conn.Close()
+	} else {
+		assert.Equal(t, context.Canceled, err)
+	}
+
+	listener.Close()
+
+	select {
+	case <-dialer.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("dialer not done after the device closed the connection")
+	}
+
+	_, err = dialer.Dial(ctx)
+	assert.Error(t, err)
+}
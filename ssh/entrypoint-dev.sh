@@ -9,4 +9,9 @@ if [ ! -f /var/run/secrets/ssh_private_key ]; then
     openssl genrsa -out /var/run/secrets/ssh_private_key 2048
 fi
 
+if [ ! -f /var/run/secrets/gateway_secret ]; then
+    echo "Generating gateway secret"
+    openssl rand -hex 32 > /var/run/secrets/gateway_secret
+fi
+
 refresh run
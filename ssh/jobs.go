@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	api "github.com/shellhub-io/shellhub/pkg/api/client"
+	"github.com/shellhub-io/shellhub/pkg/httptunnel"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// JobURL is where the API dispatches the jobs to run on the devices. It is
+// only served on the internal routes, never reached through the gateway.
+const JobURL = "/jobs/{uid}"
+
+const (
+	// jobOutputLimit is the most bytes kept of the stdout and stderr of a
+	// job on each device.
+	jobOutputLimit = 64 * 1024
+	// jobResumeDelay is how long the jobs waiting for a device are delayed
+	// after it connects, giving its tunnel time to be set up.
+	jobResumeDelay = 5 * time.Second
+	// jobKeyTTL is how long a private key is used to authenticate on the
+	// devices, which must be less than the API keeps it.
+	jobKeyTTL = 30 * time.Second
+)
+
+var (
+	ErrJobTimeout  = errors.New("command timed out")
+	ErrJobDisabled = errors.New("jobs are disabled on the device")
+)
+
+// JobRunner runs the commands of the jobs on the devices as non pty
+// sessions, bounding how many run at the same time on this instance.
+type JobRunner struct {
+	tunnel *httptunnel.Tunnel
+	client api.Client
+	slots  chan struct{}
+
+	mu        sync.Mutex
+	key       ssh.Signer
+	keyExpiry time.Time
+}
+
+func NewJobRunner(tunnel *httptunnel.Tunnel, client api.Client, concurrency int) *JobRunner {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &JobRunner{
+		tunnel: tunnel,
+		client: client,
+		slots:  make(chan struct{}, concurrency),
+	}
+}
+
+func (r *JobRunner) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	var dispatch models.JobDispatch
+	if err := json.NewDecoder(req.Body).Decode(&dispatch); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Never trust the job dispatched, but the one stored by the API
+	job, err := r.client.GetJob(mux.Vars(req)["uid"])
+	if err != nil {
+		status := http.StatusBadGateway
+		if err.Error() == api.NotFoundErr {
+			status = http.StatusNotFound
+		}
+
+		http.Error(res, err.Error(), status)
+		return
+	}
+
+	go r.run(job, dispatch.Devices)
+
+	res.WriteHeader(http.StatusAccepted)
+}
+
+func (r *JobRunner) run(job *models.Job, devices []string) {
+	logrus.WithFields(logrus.Fields{
+		"job":     job.UID,
+		"devices": len(devices),
+	}).Info("Running job")
+
+	var wg sync.WaitGroup
+
+	for _, device := range devices {
+		r.slots <- struct{}{}
+		wg.Add(1)
+
+		go func(device string) {
+			defer func() {
+				<-r.slots
+				wg.Done()
+			}()
+
+			r.runOnDevice(job, device, models.JobResultPending)
+		}(device)
+	}
+
+	wg.Wait()
+
+	logrus.WithFields(logrus.Fields{
+		"job": job.UID,
+	}).Info("Job finished")
+}
+
+// Resume runs the jobs that were waiting for the device to come back.
+func (r *JobRunner) Resume(device string) {
+	time.Sleep(jobResumeDelay)
+
+	results, err := r.client.ListWaitingJobResults(device)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"uid": device,
+			"err": err,
+		}).Error("Failed to list jobs waiting for device")
+
+		return
+	}
+
+	for _, result := range results {
+		job, err := r.client.GetJob(result.JobUID)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"job": result.JobUID,
+				"uid": device,
+				"err": err,
+			}).Error("Failed to get job waiting for device")
+
+			continue
+		}
+
+		r.slots <- struct{}{}
+		r.runOnDevice(job, device, models.JobResultWaiting)
+		<-r.slots
+	}
+}
+
+// runOnDevice runs the job on the device, unless it is no longer in status
+// because another instance or a previous connection of the device started it.
+func (r *JobRunner) runOnDevice(job *models.Job, device, status string) {
+	// Only the devices of the namespace of the job may run it
+	if d, err := r.client.GetDevice(device); err != nil || d.TenantID != job.TenantID {
+		logrus.WithFields(logrus.Fields{
+			"job": job.UID,
+			"uid": device,
+			"err": err,
+		}).Error("Refused to run job on device out of its namespace")
+
+		return
+	}
+
+	if err := r.client.StartJobResult(job.UID, device, status); err != nil {
+		if err.Error() == api.ConflictErr {
+			logrus.WithFields(logrus.Fields{
+				"job": job.UID,
+				"uid": device,
+			}).Info("Job already started on device")
+
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"job": job.UID,
+			"uid": device,
+			"err": err,
+		}).Error("Failed to start job on device")
+
+		return
+	}
+
+	startedAt := time.Now()
+
+	result := &models.JobResult{
+		JobUID:    job.UID,
+		DeviceUID: device,
+		StartedAt: &startedAt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(job.Timeout)*time.Second)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: jobOutputLimit}
+	stderr := &limitedBuffer{limit: jobOutputLimit}
+
+	exitCode, err := r.exec(ctx, job, device, stdout, stderr)
+
+	result.Duration = time.Since(startedAt).Milliseconds()
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Truncated = stdout.truncated || stderr.truncated
+
+	switch {
+	case err == errDeviceUnreachable && job.Retry:
+		// Run it again when the device comes back
+		result.Status = models.JobResultWaiting
+		result.StartedAt = nil
+		result.Duration = 0
+	case err != nil:
+		result.Status = models.JobResultFailed
+		result.Error = err.Error()
+	case exitCode != 0:
+		result.Status = models.JobResultFailed
+		result.ExitCode = &exitCode
+	default:
+		result.Status = models.JobResultSuccess
+		result.ExitCode = &exitCode
+	}
+
+	if err := r.client.SetJobResult(result); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"job": job.UID,
+			"uid": device,
+			"err": err,
+		}).Error("Failed to report job result")
+	}
+}
+
+var errDeviceUnreachable = errors.New("device unreachable")
+
+// exec runs the command of the job on the device, returning its exit code.
+func (r *JobRunner) exec(ctx context.Context, job *models.Job, device string, stdout, stderr *limitedBuffer) (int, error) {
+	signer, err := r.signer()
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := dialDevice(ctx, r.tunnel, device)
+	if err != nil {
+		return 0, errDeviceUnreachable
+	}
+	defer conn.Close()
+
+	id := fmt.Sprintf("job-%s-%s", job.UID, device)
+
+	// The agent only takes the connection over when it allows jobs
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/ssh/jobs/%s", id), nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "ssh")
+
+	if err := req.Write(conn); err != nil {
+		return 0, errDeviceUnreachable
+	}
+
+	reader := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return 0, errDeviceUnreachable
+	}
+
+	switch resp.StatusCode {
+	case http.StatusSwitchingProtocols:
+	case http.StatusForbidden, http.StatusNotFound:
+		// Agents not supporting jobs do not know the route
+		return 0, ErrJobDisabled
+	default:
+		return 0, fmt.Errorf("device refused the job: %s", resp.Status)
+	}
+
+	defer func() {
+		// Release the session on the agent as the gateway does for the users
+		if conn, err := dialDevice(context.Background(), r.tunnel, device); err == nil {
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/ssh/close/%s", id), nil)
+			req.Write(conn) // nolint:errcheck
+			conn.Close()
+		}
+	}()
+
+	// Abort the command once the job times out
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	client, err := NewClientConnWithDeadline(&bufferedConn{Conn: conn, reader: reader}, "tcp", &ssh.ClientConfig{
+		User:            job.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // nolint:gosec
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ErrJobTimeout
+		}
+
+		return 0, err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return 0, err
+	}
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	err = session.Run(shellCommand(job.Command))
+	if ctx.Err() != nil {
+		return 0, ErrJobTimeout
+	}
+
+	if exitErr, ok := err.(*ssh.ExitError); ok {
+		return exitErr.ExitStatus(), nil
+	}
+
+	return 0, err
+}
+
+// signer returns the key the devices are authenticated with, which the API
+// signs the challenges of the devices with for a short while.
+func (r *JobRunner) signer() (ssh.Signer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.key != nil && time.Now().Before(r.keyExpiry) {
+		return r.key, nil
+	}
+
+	key, err := r.client.CreatePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(key.Data)
+	if block == nil {
+		return nil, errors.New("invalid private key")
+	}
+
+	privKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.NewSignerFromKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+
+	r.key = signer
+	r.keyExpiry = time.Now().Add(jobKeyTTL)
+
+	return signer, nil
+}
+
+// shellCommand runs the command through the shell of the device, allowing
+// pipes and redirections.
+func shellCommand(command string) string {
+	return "sh -c '" + strings.ReplaceAll(command, "'", `'\''`) + "'"
+}
+
+// limitedBuffer keeps up to limit bytes written to it, discarding the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
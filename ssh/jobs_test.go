@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	api "github.com/shellhub-io/shellhub/pkg/api/client"
+	"github.com/shellhub-io/shellhub/pkg/httptunnel"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// jobClient serves the jobs and devices the runner reads from the API,
+// recording the results started.
+type jobClient struct {
+	api.Client
+
+	jobs    map[string]*models.Job
+	devices map[string]*models.Device
+
+	mu      sync.Mutex
+	started []string
+}
+
+func (c *jobClient) GetJob(uid string) (*models.Job, error) {
+	if job, ok := c.jobs[uid]; ok {
+		return job, nil
+	}
+
+	return nil, errors.New(api.NotFoundErr)
+}
+
+func (c *jobClient) GetDevice(uid string) (*models.Device, error) {
+	if device, ok := c.devices[uid]; ok {
+		return device, nil
+	}
+
+	return nil, errors.New(api.NotFoundErr)
+}
+
+func (c *jobClient) StartJobResult(job, device, status string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.started = append(c.started, device)
+
+	// Stop before running the command, as other instance would
+	return errors.New(api.ConflictErr)
+}
+
+func TestJobRunnerDispatch(t *testing.T) {
+	client := &jobClient{
+		jobs: map[string]*models.Job{
+			"job": {UID: "job", TenantID: "tenant", Command: "uptime"},
+		},
+		devices: map[string]*models.Device{
+			"device": {UID: "device", TenantID: "tenant"},
+			"other":  {UID: "other", TenantID: "other"},
+		},
+	}
+
+	runner := NewJobRunner(httptunnel.NewTunnel("/connection", "/revdial"), client, 1)
+
+	router := mux.NewRouter()
+	router.Handle(JobURL, runner).Methods(http.MethodPost)
+
+	cases := []struct {
+		name   string
+		uid    string
+		status int
+	}{
+		{"stored job", "job", http.StatusAccepted},
+		{"unknown job", "unknown", http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := `{"job": {"uid": "` + tc.uid + `", "command": "reboot"}, "devices": []}`
+			req := httptest.NewRequest(http.MethodPost, strings.Replace(JobURL, "{uid}", tc.uid, 1), strings.NewReader(body))
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.status, res.Code)
+		})
+	}
+
+	// The devices out of the namespace of the job, or unknown, never run it
+	runner.run(client.jobs["job"], []string{"device", "other", "unknown"})
+
+	assert.Equal(t, []string{"device"}, client.started)
+}
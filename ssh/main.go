@@ -1,25 +1,43 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	sshserver "github.com/gliderlabs/ssh"
 	"github.com/gorilla/mux"
+	"github.com/kelseyhightower/envconfig"
 	"golang.org/x/net/websocket"
 
-	"github.com/parnurzeal/gorequest"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	api "github.com/shellhub-io/shellhub/pkg/api/client"
+	"github.com/shellhub-io/shellhub/pkg/connman"
 	"github.com/shellhub-io/shellhub/pkg/httptunnel"
 	"github.com/sirupsen/logrus"
 )
 
 var magicKey *rsa.PrivateKey
 
+var ErrGatewayShuttingDown = errors.New("gateway is shutting down")
+
+// singleInstance identifies the instance in the tunnel registry when no
+// address is set, as no other instance has to reach it.
+const singleInstance = "local"
+
 func main() {
 	opts := &Options{
 		Addr:           ":2222",
@@ -27,11 +45,89 @@ func main() {
 		ConnectTimeout: 30 * time.Second,
 	}
 
+	gatewayOpts := GatewayOptions{}
+	if err := envconfig.Process("", &gatewayOpts); err != nil {
+		logrus.Fatal(err)
+	}
+
+	apiClient := api.NewClient()
+
+	instance := gatewayOpts.Address
+	if instance == "" {
+		instance = singleInstance
+	}
+
+	presence := NewPresenceTracker(apiClient, instance, gatewayOpts.PresenceDebounce)
+	if err := presence.Reconcile(); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Failed to reconcile device presence")
+	}
+
 	tunnel := httptunnel.NewTunnel("/ssh/connection", "/ssh/revdial")
+
+	registerTunnelsGauge(tunnel)
+
+	// The instances share the tunnels only when they can reach each other
+	if gatewayOpts.Address != "" {
+		secret, err := ioutil.ReadFile(gatewayOpts.SecretFile)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		tunnel.Instance = gatewayOpts.Address
+		tunnel.Secret = strings.TrimSpace(string(secret))
+		if tunnel.Secret == "" {
+			logrus.Fatal("the gateway secret is empty")
+		}
+
+		tunnel.DialFallback = func(ctx context.Context, uid string) (net.Conn, error) {
+			// Forward the connection through the instance holding the tunnel
+			t, err := apiClient.GetTunnel(uid)
+			if err != nil || t.Instance == tunnel.Instance {
+				return nil, connman.ErrNoConnection
+			}
+
+			return httptunnel.DialForward(ctx, t.Instance, uid, tunnel.Secret)
+		}
+	}
+
+	runner := NewJobRunner(tunnel, apiClient, gatewayOpts.JobConcurrency)
+
+	var draining int32
+
 	tunnel.ConnectionHandler = func(r *http.Request) (string, error) {
+		if atomic.LoadInt32(&draining) == 1 {
+			return "", ErrGatewayShuttingDown
+		}
+
 		uid := r.Header.Get(api.DeviceUIDHeader)
+
+		if err := apiClient.RegisterTunnel(uid, instance); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"uid": uid,
+				"err": err,
+			}).Error("Failed to register tunnel")
+		}
+
+		presence.Connected(uid)
+
+		go runner.Resume(uid)
+
 		return uid, nil
 	}
+	tunnel.CloseHandler = func(uid string) {
+		// Not found when the device is already connected to other instance,
+		// which the presence tracker checks before reporting it offline
+		if err := apiClient.UnregisterTunnel(uid, instance); err != nil && err.Error() != api.NotFoundErr {
+			logrus.WithFields(logrus.Fields{
+				"uid": uid,
+				"err": err,
+			}).Error("Failed to unregister tunnel")
+		}
+
+		presence.Disconnected(uid)
+	}
 
 	router := tunnel.Router().(*mux.Router)
 	router.HandleFunc("/sessions/{uid}/close", func(res http.ResponseWriter, req *http.Request) {
@@ -46,32 +142,50 @@ func main() {
 			return
 		}
 
-		conn, err := tunnel.Dial(context.Background(), closeRequest.Device)
+		conn, err := dialDevice(req.Context(), tunnel, closeRequest.Device)
 		if err != nil {
 			http.Error(res, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		defer conn.Close()
 
-		req, _ = http.NewRequest("DELETE", fmt.Sprintf("/ssh/close/%s", vars["uid"]), nil)
-		if err := req.Write(conn); err != nil {
+		closeReq, _ := http.NewRequest("DELETE", fmt.Sprintf("/ssh/close/%s", vars["uid"]), nil)
+		if err := closeReq.Write(conn); err != nil {
 			http.Error(res, err.Error(), http.StatusInternalServerError)
 			return
 		}
+
+		// Reply with the status of the agent so the api knows whether the
+		// session was actually closed
+		resp, err := http.ReadResponse(bufio.NewReader(conn), closeReq)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadGateway)
+			return
+		}
+		resp.Body.Close()
+
+		res.WriteHeader(resp.StatusCode)
 	})
 	router.Handle("/ws/ssh", websocket.Handler(HandlerWebsocket))
+	router.Handle(TCPURL, NewTCPHandler(tunnel, apiClient))
+	router.Handle(ShadowURL, NewShadowHandler(tunnel.Instance, apiClient))
+	// The internal routes are only reached by the other services, as the
+	// gateway in front of the instances never proxies them
+	internal := router.PathPrefix("/internal").Subrouter()
+	internal.Handle(JobURL, runner).Methods(http.MethodPost)
+	router.Handle("/metrics", promhttp.Handler())
 
-	go http.ListenAndServe(":8080", router) // nolint:errcheck
+	httpServer := &http.Server{Addr: ":8080", Handler: router}
+	go httpServer.ListenAndServe() // nolint:errcheck
 
-	server := NewServer(opts, tunnel)
+	// The HTTP services of the devices are only served on their own listener,
+	// reached by the authenticated server block of the device domain
+	proxyServer := &http.Server{Addr: ProxyAddress, Handler: NewProxy(tunnel, apiClient, gatewayOpts.DeviceProxyDomain)}
+	if gatewayOpts.DeviceProxyDomain != "" {
+		go proxyServer.ListenAndServe() // nolint:errcheck
+	}
 
-	go func() {
-		for {
-			id, online := tunnel.Online()
-			if !online {
-				_, _, _ = gorequest.New().Post(fmt.Sprintf("http://api:8080/internal/devices/%s/offline", id)).End()
-			}
-		}
-	}()
+	server := NewServer(opts, tunnel)
 
 	var err error
 	magicKey, err = rsa.GenerateKey(rand.Reader, 2048)
@@ -79,5 +193,26 @@ func main() {
 		logrus.Fatal(err)
 	}
 
-	logrus.Fatal(server.ListenAndServe())
+	go func() {
+		if err := server.ListenAndServe(); err != sshserver.ErrServerClosed {
+			logrus.Fatal(err)
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+
+	logrus.Info("Shutting down gateway")
+
+	// Refuse new device tunnels so devices reconnect to other instances
+	atomic.StoreInt32(&draining, 1)
+
+	server.Shutdown(gatewayOpts.DrainPeriod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	httpServer.Shutdown(ctx)  // nolint:errcheck
+	proxyServer.Shutdown(ctx) // nolint:errcheck
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shellhub-io/shellhub/pkg/httptunnel"
+)
+
+var (
+	sessionsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "shellhub",
+		Subsystem: "gateway",
+		Name:      "sessions",
+		Help:      "Number of active SSH sessions.",
+	})
+
+	dialDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "shellhub",
+		Subsystem: "gateway",
+		Name:      "dial_duration_seconds",
+		Help:      "Time spent dialing devices through the reverse tunnel.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	dialFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "shellhub",
+		Subsystem: "gateway",
+		Name:      "dial_failures_total",
+		Help:      "Number of failed dials to devices through the reverse tunnel.",
+	})
+
+	proxiedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "shellhub",
+		Subsystem: "gateway",
+		Name:      "proxied_bytes_total",
+		Help:      "Number of bytes proxied between clients and devices, by direction.",
+	}, []string{"direction"})
+)
+
+func init() {
+	prometheus.MustRegister(sessionsGauge, dialDuration, dialFailures, proxiedBytes)
+}
+
+// registerTunnelsGauge registers the gauge of the devices connected to the
+// tunnel, read from the tunnel itself so it never drifts from it.
+func registerTunnelsGauge(tunnel *httptunnel.Tunnel) {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "shellhub",
+		Subsystem: "gateway",
+		Name:      "tunnels",
+		Help:      "Number of devices with an open reverse tunnel.",
+	}, func() float64 {
+		return float64(tunnel.Len())
+	}))
+}
+
+// dialDevice dials to the device through the tunnel recording the latency
+// and failures of the dial.
+func dialDevice(ctx context.Context, tunnel *httptunnel.Tunnel, uid string) (net.Conn, error) {
+	start := time.Now()
+
+	conn, err := tunnel.Dial(ctx, uid)
+	if err != nil {
+		dialFailures.Inc()
+		return nil, err
+	}
+
+	dialDuration.Observe(time.Since(start).Seconds())
+
+	return &meteredConn{Conn: conn}, nil
+}
+
+// meteredConn counts the bytes received from (in) and sent to (out) the
+// device.
+type meteredConn struct {
+	net.Conn
+}
+
+func (c *meteredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	proxiedBytes.WithLabelValues("in").Add(float64(n))
+
+	return n, err
+}
+
+func (c *meteredConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	proxiedBytes.WithLabelValues("out").Add(float64(n))
+
+	return n, err
+}
@@ -7,3 +7,27 @@ type Options struct {
 	Broker         string
 	ConnectTimeout time.Duration
 }
+
+type GatewayOptions struct {
+	// Address (host:port) where the other gateway instances reach this one.
+	// It identifies the instance in the tunnel registry, so it must be stable
+	// across restarts. Leave it empty to run a single instance.
+	Address string `envconfig:"gateway_address"`
+	// SecretFile is the path to the secret shared by the instances, which
+	// authenticates the connections forwarded between them. Only required
+	// along with Address.
+	SecretFile string `envconfig:"gateway_secret_file" default:"/run/secrets/gateway_secret"`
+	// PresenceDebounce is how long a device must stay disconnected before it
+	// is reported offline.
+	PresenceDebounce time.Duration `envconfig:"presence_debounce" default:"10s"`
+	// DrainPeriod is how long active sessions are kept on shutdown before
+	// being closed.
+	DrainPeriod time.Duration `envconfig:"drain_period" default:"30s"`
+	// DeviceProxyDomain is the domain the HTTP services of the devices are
+	// reached at by host (<port>-<device>.<namespace>.<domain>). Leave it
+	// empty to disable the proxy.
+	DeviceProxyDomain string `envconfig:"device_proxy_domain"`
+	// JobConcurrency is how many devices run the commands of the jobs at the
+	// same time on this instance.
+	JobConcurrency int `envconfig:"job_concurrency" default:"10"`
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	api "github.com/shellhub-io/shellhub/pkg/api/client"
+	"github.com/sirupsen/logrus"
+)
+
+type presenceEvent struct {
+	uid    string
+	online bool
+	at     time.Time
+}
+
+// PresenceTracker reports to the api when the tunnel of a device goes online
+// or offline. Disconnections are only reported after a debounce period so a
+// device whose tunnel flaps is not seen going offline and back online.
+type PresenceTracker struct {
+	client   api.Client
+	instance string
+	debounce time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*time.Timer
+	events  chan presenceEvent
+}
+
+func NewPresenceTracker(client api.Client, instance string, debounce time.Duration) *PresenceTracker {
+	p := &PresenceTracker{
+		client:   client,
+		instance: instance,
+		debounce: debounce,
+		pending:  make(map[string]*time.Timer),
+		events:   make(chan presenceEvent, 1024),
+	}
+
+	// Events are reported by a single goroutine to keep them in order
+	go p.report()
+
+	return p
+}
+
+// Connected is called when the tunnel of a device is established.
+func (p *PresenceTracker) Connected(uid string) {
+	p.mu.Lock()
+	timer, ok := p.pending[uid]
+	if ok {
+		delete(p.pending, uid)
+		timer.Stop()
+	}
+	p.mu.Unlock()
+
+	// The device reconnected before its disconnection was reported
+	if ok {
+		return
+	}
+
+	p.events <- presenceEvent{uid: uid, online: true, at: time.Now()}
+}
+
+// Disconnected is called when the tunnel of a device is closed.
+func (p *PresenceTracker) Disconnected(uid string) {
+	at := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.pending[uid]; ok {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(p.debounce, func() {
+		p.mu.Lock()
+		if p.pending[uid] != timer {
+			p.mu.Unlock()
+			return
+		}
+		delete(p.pending, uid)
+		p.mu.Unlock()
+
+		// The device may have reconnected to other instance meanwhile
+		if tunnel, err := p.client.GetTunnel(uid); err == nil && tunnel.Instance != p.instance {
+			return
+		}
+
+		p.events <- presenceEvent{uid: uid, online: false, at: at}
+	})
+
+	p.pending[uid] = timer
+}
+
+// Reconcile marks as disconnected the devices the registry still assigns to
+// this instance, which are left behind when the gateway restarts. It must be
+// called before accepting tunnel connections.
+func (p *PresenceTracker) Reconcile() error {
+	tunnels, err := p.client.ListTunnels(p.instance)
+	if err != nil {
+		return err
+	}
+
+	for _, tunnel := range tunnels {
+		if err := p.client.UnregisterTunnel(tunnel.UID, p.instance); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"uid": tunnel.UID,
+				"err": err,
+			}).Error("Failed to unregister stale tunnel")
+		}
+
+		p.Disconnected(tunnel.UID)
+	}
+
+	return nil
+}
+
+func (p *PresenceTracker) report() {
+	for event := range p.events {
+		var err error
+		if event.online {
+			err = p.client.DeviceOnline(event.uid, event.at)
+		} else {
+			err = p.client.DeviceOffline(event.uid, event.at)
+		}
+
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"uid":    event.uid,
+				"online": event.online,
+				"err":    err,
+			}).Error("Failed to report device presence")
+		}
+	}
+}
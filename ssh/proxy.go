@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/shellhub-io/shellhub/pkg/api/client"
+	"github.com/shellhub-io/shellhub/pkg/httptunnel"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ProxyAddress is where the gateway serves the HTTP services of the devices,
+// only reached by the server block of the device domain in front of it.
+const ProxyAddress = ":8081"
+
+// proxyDialTimeout bounds the time to reach the device.
+const proxyDialTimeout = 30 * time.Second
+
+// tokenCookie is the cookie holding the token of the user, set by the API.
+const tokenCookie = "token"
+
+// hopHeaders are the headers meaningful only for a single connection, which
+// are not forwarded.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Proxy forwards HTTP requests to the web servers listening on the devices
+// through their tunnel, reached by host (<port>-<device>.<namespace>.<domain>)
+// so that they never share the origin of the web UI. The requests must be
+// authenticated by the gateway in front of it, which sets the tenant of the
+// user in the X-Tenant-ID header.
+type Proxy struct {
+	tunnel *httptunnel.Tunnel
+	client api.Client
+	domain string
+}
+
+func NewProxy(tunnel *httptunnel.Tunnel, client api.Client, domain string) *Proxy {
+	return &Proxy{tunnel: tunnel, client: client, domain: domain}
+}
+
+func (p *Proxy) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	port, name, namespace, ok := p.parseHost(req.Host)
+	if !ok {
+		http.NotFound(res, req)
+		return
+	}
+
+	device, err := p.client.LookupDevice(namespace, name)
+	if err != nil {
+		http.Error(res, "device not found", http.StatusNotFound)
+		return
+	}
+
+	p.forward(res, req, device, port)
+}
+
+// parseHost splits a host in the <port>-<device>.<namespace>.<domain> form.
+func (p *Proxy) parseHost(host string) (port, device, namespace string, ok bool) {
+	if p.domain == "" {
+		return "", "", "", false
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	suffix := "." + strings.ToLower(p.domain)
+
+	host = strings.ToLower(host)
+	if !strings.HasSuffix(host, suffix) {
+		return "", "", "", false
+	}
+
+	labels := strings.SplitN(strings.TrimSuffix(host, suffix), ".", 2)
+	if len(labels) != 2 || labels[1] == "" {
+		return "", "", "", false
+	}
+
+	parts := strings.SplitN(labels[0], "-", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", "", false
+	}
+
+	if !validPort(parts[0]) {
+		return "", "", "", false
+	}
+
+	return parts[0], parts[1], labels[1], true
+}
+
+// forward sends the request to the port of the device and writes back the
+// response.
+func (p *Proxy) forward(res http.ResponseWriter, req *http.Request, device *models.Device, port string) {
+	tenant := req.Header.Get("X-Tenant-ID")
+	if tenant == "" {
+		http.Error(res, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Do not disclose the devices of other namespaces
+	if device.TenantID != tenant || device.Status != "accepted" {
+		http.Error(res, "device not found", http.StatusNotFound)
+		return
+	}
+
+	if !validPort(port) {
+		http.Error(res, "invalid port", http.StatusBadRequest)
+		return
+	}
+
+	outreq := req.Clone(req.Context())
+	outreq.URL = &url.URL{Path: "/http/" + port + req.URL.Path, RawQuery: req.URL.RawQuery}
+	outreq.RequestURI = ""
+	outreq.Close = true
+
+	for _, header := range hopHeaders {
+		outreq.Header.Del(header)
+	}
+
+	// The credentials of the user are not meant for the device
+	outreq.Header.Del("Authorization")
+	outreq.Header.Del("X-Tenant-ID")
+	removeCookie(outreq, tokenCookie)
+
+	if ip, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		if prior := req.Header.Get("X-Forwarded-For"); prior != "" {
+			ip = prior + ", " + ip
+		}
+
+		outreq.Header.Set("X-Forwarded-For", ip)
+	}
+
+	outreq.Header.Set("X-Forwarded-Host", req.Host)
+
+	ctx, cancel := context.WithTimeout(req.Context(), proxyDialTimeout)
+	defer cancel()
+
+	resp, err := p.tunnel.SendRequest(ctx, device.UID, outreq)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"uid":  device.UID,
+			"port": port,
+			"err":  err,
+		}).Error("Failed to proxy request to device")
+
+		http.Error(res, "failed to reach device", http.StatusBadGateway)
+		return
+	}
+
+	for _, header := range hopHeaders {
+		resp.Header.Del(header)
+	}
+
+	p.tunnel.ForwardResponse(resp, res)
+}
+
+// removeCookie removes the cookie named name from the request.
+func removeCookie(req *http.Request, name string) {
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			req.AddCookie(cookie)
+		}
+	}
+}
+
+// portRegexp only allows plain digits, as Atoi also accepts signs.
+var portRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+func validPort(port string) bool {
+	if !portRegexp.MatchString(port) {
+		return false
+	}
+
+	n, err := strconv.Atoi(port)
+
+	return err == nil && n > 0 && n <= 65535
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHost(t *testing.T) {
+	p := &Proxy{domain: "devices.example.com"}
+
+	cases := []struct {
+		name      string
+		host      string
+		port      string
+		device    string
+		namespace string
+		ok        bool
+	}{
+		{"device", "80-device.namespace.devices.example.com", "80", "device", "namespace", true},
+		{"with port", "8080-device.namespace.devices.example.com:443", "8080", "device", "namespace", true},
+		{"upper case", "80-Device.NameSpace.Devices.Example.com", "80", "device", "namespace", true},
+		{"dashed device", "80-my-device.namespace.devices.example.com", "80", "my-device", "namespace", true},
+		{"signed port", "+80-device.namespace.devices.example.com", "", "", "", false},
+		{"negative port", "-80-device.namespace.devices.example.com", "", "", "", false},
+		{"zero port", "0-device.namespace.devices.example.com", "", "", "", false},
+		{"port out of range", "65536-device.namespace.devices.example.com", "", "", "", false},
+		{"no port", "device.namespace.devices.example.com", "", "", "", false},
+		{"no device", "80-.namespace.devices.example.com", "", "", "", false},
+		{"no namespace", "80-device.devices.example.com", "", "", "", false},
+		{"other domain", "80-device.namespace.example.com", "", "", "", false},
+		{"domain suffix", "80-device.namespace.otherdevices.example.com", "", "", "", false},
+		{"domain only", "devices.example.com", "", "", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			port, device, namespace, ok := p.parseHost(tc.host)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.port, port)
+			assert.Equal(t, tc.device, device)
+			assert.Equal(t, tc.namespace, namespace)
+		})
+	}
+
+	_, _, _, ok := (&Proxy{}).parseHost("80-device.namespace.devices.example.com")
+	assert.False(t, ok)
+}
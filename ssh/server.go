@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	sshserver "github.com/gliderlabs/ssh"
@@ -25,12 +26,19 @@ type Server struct {
 	sshd   *sshserver.Server
 	opts   *Options
 	tunnel *httptunnel.Tunnel
+
+	mu       sync.Mutex
+	listener net.Listener
+	sessions map[*Session]struct{}
+	draining bool
+	done     sync.WaitGroup
 }
 
 func NewServer(opts *Options, tunnel *httptunnel.Tunnel) *Server {
 	s := &Server{
-		opts:   opts,
-		tunnel: tunnel,
+		opts:     opts,
+		tunnel:   tunnel,
+		sessions: make(map[*Session]struct{}),
 	}
 
 	s.sshd = &sshserver.Server{
@@ -57,6 +65,11 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 		"session": session.Context().Value(sshserver.ContextKeySessionID),
 	}).Info("Handling session request")
 
+	if username, uid, write, ok := parseShadowTarget(session.User()); ok {
+		s.shadowHandler(session, username, uid, write)
+		return
+	}
+
 	sess, err := NewSession(session.User(), session)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
@@ -73,6 +86,15 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 		return
 	}
 
+	sess.Instance = s.tunnel.Instance
+
+	if !s.track(sess) {
+		io.WriteString(session, "The gateway is shutting down, please try again\n") // nolint:errcheck
+		session.Close()
+		return
+	}
+	defer s.untrack(sess)
+
 	wh := webhook.NewClient()
 	if wh != nil {
 		res, err := wh.Connect(sess.Lookup)
@@ -90,7 +112,7 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 		}
 	}
 
-	conn, err := s.tunnel.Dial(context.Background(), sess.Target)
+	conn, err := dialDevice(context.Background(), s.tunnel, sess.Target)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"err":     err,
@@ -101,6 +123,9 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 		return
 	}
 
+	sessionsGauge.Inc()
+	defer sessionsGauge.Dec()
+
 	logrus.WithFields(logrus.Fields{
 		"target":   sess.Target,
 		"username": sess.User,
@@ -177,7 +202,7 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 		session.Close()
 	}
 
-	conn, err = s.tunnel.Dial(context.Background(), sess.Target)
+	conn, err = dialDevice(context.Background(), s.tunnel, sess.Target)
 	if err != nil {
 		return
 	}
@@ -192,6 +217,11 @@ func (s *Server) sessionHandler(session sshserver.Session) {
 }
 
 func (*Server) publicKeyHandler(ctx sshserver.Context, pubKey sshserver.PublicKey) bool {
+	// Users attaching to sessions authenticate with the password of their account
+	if _, _, _, ok := parseShadowTarget(ctx.User()); ok {
+		return false
+	}
+
 	fingerprint := ssh.FingerprintLegacyMD5(pubKey)
 
 	magicPubKey, err := ssh.NewPublicKey(&magicKey.PublicKey)
@@ -232,5 +262,115 @@ func (s *Server) ListenAndServe() error {
 	proxyListener := &proxyproto.Listener{Listener: list}
 	defer proxyListener.Close()
 
-	return s.sshd.Serve(proxyListener)
+	s.mu.Lock()
+	s.listener = proxyListener
+	draining := s.draining
+	s.mu.Unlock()
+
+	if draining {
+		return sshserver.ErrServerClosed
+	}
+
+	err = s.sshd.Serve(proxyListener)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return sshserver.ErrServerClosed
+	}
+
+	return err
+}
+
+// Shutdown stops accepting new connections and lets the active sessions run
+// for the drain period after notifying them. Sessions still active after that
+// are closed and marked as finished.
+func (s *Server) Shutdown(drain time.Duration) {
+	s.mu.Lock()
+	s.draining = true
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	sessions := make([]*Session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"sessions": len(sessions),
+		"drain":    drain,
+	}).Info("Draining sessions")
+
+	for _, sess := range sessions {
+		sess.notify(fmt.Sprintf("*** The gateway is shutting down, this session will be closed in %s ***", drain))
+	}
+
+	if s.wait(drain) {
+		return
+	}
+
+	s.mu.Lock()
+	sessions = sessions[:0]
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		sess.notify("*** The gateway is shutting down, closing session ***")
+
+		if err := sess.finish(); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"session": sess.UID,
+				"err":     err,
+			}).Error("Failed to finish session")
+		}
+
+		sess.session.Close()
+	}
+
+	// Give the session handlers a moment to clean up
+	s.wait(5 * time.Second)
+}
+
+// track registers an active session, reporting false if the server is
+// draining and must not accept new sessions.
+func (s *Server) track(sess *Session) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.draining {
+		return false
+	}
+
+	s.sessions[sess] = struct{}{}
+	s.done.Add(1)
+
+	return true
+}
+
+func (s *Server) untrack(sess *Session) {
+	s.mu.Lock()
+	delete(s.sessions, sess)
+	s.mu.Unlock()
+
+	s.done.Done()
+}
+
+// wait waits for all the active sessions to end, reporting false on timeout.
+func (s *Server) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.done.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
 }
@@ -8,8 +8,8 @@ import (
 	"io"
 	"net"
 	"net/http"
-	"os"
 	"strings"
+	"sync"
 	"time"
 
 	sshserver "github.com/gliderlabs/ssh"
@@ -29,8 +29,10 @@ type Session struct {
 	UID           string `json:"uid"`
 	IPAddress     string `json:"ip_address"`
 	Authenticated bool   `json:"authenticated"`
+	Instance      string `json:"instance"`
 	Lookup        map[string]string
 	Pty           bool
+	finished      sync.Once
 }
 
 type ConfigOptions struct {
@@ -106,7 +108,12 @@ func NewSession(target string, session sshserver.Session) (*Session, error) {
 	s.Target = device.UID
 	s.Lookup = lookup
 
-	if os.Getenv("SHELLHUB_ENTERPRISE") == "true" {
+	firewall, err := hasFeature(models.FeatureFirewall)
+	if err != nil {
+		return nil, err
+	}
+
+	if firewall {
 		res, _, errs := gorequest.New().Get("http://cloud-api:8080/internal/firewall/rules/evaluate").Query(lookup).End()
 		if len(errs) > 0 || res.StatusCode != http.StatusOK {
 			return nil, ErrInvalidSessionTarget
@@ -118,6 +125,100 @@ func NewSession(target string, session sshserver.Session) (*Session, error) {
 	return s, nil
 }
 
+// featuresTTL is how long the features of the server are cached.
+const featuresTTL = time.Minute
+
+// featuresURL is where the features of the server are fetched from.
+var featuresURL = "http://api:8080/internal/features"
+
+var ErrFeaturesUnavailable = errors.New("failed to get the features of the server")
+
+var features struct {
+	sync.Mutex
+	list      []string
+	fetchedAt time.Time
+	// refresh is closed when the fetch in flight, if any, is done.
+	refresh chan struct{}
+}
+
+// hasFeature reports whether the license of the server enables feature,
+// failing when the features were never fetched so the caller can refuse
+// anything depending on them.
+func hasFeature(feature string) (bool, error) {
+	list, err := loadFeatures()
+	if err != nil {
+		return false, err
+	}
+
+	for _, f := range list {
+		if f == feature {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// loadFeatures returns the cached features of the server, refreshing them
+// when expired. The last known features are kept while they are refreshed
+// and when the refresh fails, so only the first fetch is waited for.
+func loadFeatures() ([]string, error) {
+	features.Lock()
+
+	if !features.fetchedAt.IsZero() && time.Since(features.fetchedAt) <= featuresTTL {
+		defer features.Unlock()
+		return features.list, nil
+	}
+
+	// A single fetch is in flight at a time, never holding the lock
+	done := features.refresh
+	if done == nil {
+		done = make(chan struct{})
+		features.refresh = done
+
+		go refreshFeatures(done)
+	}
+
+	if !features.fetchedAt.IsZero() {
+		defer features.Unlock()
+		return features.list, nil
+	}
+
+	features.Unlock()
+
+	<-done
+
+	features.Lock()
+	defer features.Unlock()
+
+	if features.fetchedAt.IsZero() {
+		return nil, ErrFeaturesUnavailable
+	}
+
+	return features.list, nil
+}
+
+func refreshFeatures(done chan struct{}) {
+	defer close(done)
+
+	var res models.Features
+
+	resp, _, errs := gorequest.New().Timeout(10 * time.Second).Get(featuresURL).EndStruct(&res)
+
+	features.Lock()
+	defer features.Unlock()
+
+	features.refresh = nil
+
+	if len(errs) > 0 || resp.StatusCode != http.StatusOK {
+		logrus.WithFields(logrus.Fields{"errs": errs}).Error("Failed to get the features of the server")
+		return
+	}
+
+	features.list = res.Features
+	features.fetchedAt = time.Now()
+}
+
 func (s *Session) connect(passwd string, key *rsa.PrivateKey, session sshserver.Session, conn net.Conn) error {
 	opts := ConfigOptions{}
 	err := envconfig.Process("", &opts)
@@ -190,6 +291,9 @@ func (s *Session) connect(passwd string, key *rsa.PrivateKey, session sshserver.
 			return err
 		}
 
+		shadow := shadows.register(s, stdin)
+		defer shadows.unregister(shadow)
+
 		go func() {
 			if _, err = io.Copy(stdin, s.session); err != nil {
 				logrus.WithFields(logrus.Fields{
@@ -218,7 +322,7 @@ func (s *Session) connect(passwd string, key *rsa.PrivateKey, session sshserver.
 			}
 			for {
 				bufReader := bytes.NewReader(buf[:n])
-				if _, err = io.Copy(s.session, bufReader); err != nil {
+				if _, err = io.Copy(io.MultiWriter(s.session, shadow), bufReader); err != nil {
 					logrus.WithFields(logrus.Fields{
 						"session": s.UID,
 						"err":     err,
@@ -328,7 +432,7 @@ func (s *Session) connect(passwd string, key *rsa.PrivateKey, session sshserver.
 }
 
 func (s *Session) register(_ sshserver.Session) error {
-	_, _, errs := gorequest.New().Post("http://api:8080/internal/sessions").Send(*s).End()
+	_, _, errs := gorequest.New().Post("http://api:8080/internal/sessions").Send(s).End()
 	if len(errs) > 0 {
 		return errs[0]
 	}
@@ -336,13 +440,29 @@ func (s *Session) register(_ sshserver.Session) error {
 	return nil
 }
 
-func (s *Session) finish() error {
-	_, _, errs := gorequest.New().Post(fmt.Sprintf("http://api:8080/internal/sessions/%s/finish", s.UID)).End()
-	if len(errs) > 0 {
-		return errs[0]
+// finish marks the session as finished. Only the first call reaches the
+// API, as both the session handler and the shutdown of the gateway finish
+// the sessions closed while draining.
+func (s *Session) finish() (err error) {
+	s.finished.Do(func() {
+		_, _, errs := gorequest.New().Post(fmt.Sprintf("http://api:8080/internal/sessions/%s/finish", s.UID)).End()
+		if len(errs) > 0 {
+			err = errs[0]
+		}
+	})
+
+	return err
+}
+
+// notify writes a message to the client of the session.
+func (s *Session) notify(msg string) {
+	if s.Pty {
+		msg = "\r\n" + msg + "\r\n"
+	} else {
+		msg += "\n"
 	}
 
-	return nil
+	io.WriteString(s.session.Stderr(), msg) // nolint:errcheck
 }
 
 func loadEnv(env []string) map[string]string {
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// resetFeatures waits for the fetch in flight, if any, and forgets the
+// features fetched.
+func resetFeatures() {
+	features.Lock()
+	done := features.refresh
+	features.Unlock()
+
+	if done != nil {
+		<-done
+	}
+
+	features.Lock()
+	features.list = nil
+	features.fetchedAt = time.Time{}
+	features.Unlock()
+}
+
+func TestHasFeature(t *testing.T) {
+	resetFeatures()
+	defer resetFeatures()
+
+	var requests int32
+	var failing int32
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+
+		if atomic.LoadInt32(&failing) == 1 {
+			res.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(res).Encode(&models.Features{Features: []string{models.FeatureFirewall}}) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	featuresURL = server.URL
+
+	// Not fetched yet and the server failing
+	atomic.StoreInt32(&failing, 1)
+	close(release)
+
+	_, err := hasFeature(models.FeatureFirewall)
+	assert.Equal(t, ErrFeaturesUnavailable, err)
+
+	// The first fetch is shared by the sessions waiting for it
+	atomic.StoreInt32(&failing, 0)
+	atomic.StoreInt32(&requests, 0)
+	release = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ok, err := hasFeature(models.FeatureFirewall)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Expired features are kept while refreshed, even when the refresh fails
+	atomic.StoreInt32(&failing, 1)
+	release = make(chan struct{})
+
+	features.Lock()
+	features.fetchedAt = time.Now().Add(-2 * featuresTTL)
+	features.Unlock()
+
+	ok, err := hasFeature(models.FeatureFirewall)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	features.Lock()
+	done := features.refresh
+	features.Unlock()
+
+	close(release)
+	<-done
+
+	ok, err = hasFeature("other")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
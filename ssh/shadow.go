@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+
+	sshserver "github.com/gliderlabs/ssh"
+	"github.com/gorilla/websocket"
+	"github.com/parnurzeal/gorequest"
+	api "github.com/shellhub-io/shellhub/pkg/api/client"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/shellhub-io/shellhub/pkg/wsconnadapter"
+	"github.com/sirupsen/logrus"
+)
+
+// ShadowURL is where the web terminal attaches to active sessions, e.g.
+// /ws/shadow?session=<uid>&write=true. The requests must be authenticated by
+// the gateway in front of it, which sets the X-Tenant-ID and X-Username
+// headers.
+const ShadowURL = "/ws/shadow"
+
+// shadowPrefix marks the SSH targets attaching to active sessions, e.g.
+// ssh <username>@shadow:<uid>[:rw]@<server>.
+const shadowPrefix = "shadow:"
+
+// shadowBacklog is the number of writes buffered for each viewer, which is
+// dropped when it can't keep up with the session.
+const shadowBacklog = 256
+
+var ErrShadowClosed = errors.New("session is closed")
+
+var shadows = &shadowRegistry{shadows: make(map[string]*shadow)}
+
+// shadowRegistry holds the pty sessions running on this instance that may
+// be shadowed.
+type shadowRegistry struct {
+	mu      sync.Mutex
+	shadows map[string]*shadow
+}
+
+func (r *shadowRegistry) register(sess *Session, stdin io.Writer) *shadow {
+	sh := &shadow{
+		session: sess,
+		stdin:   stdin,
+		viewers: make(map[*viewer]struct{}),
+	}
+
+	r.mu.Lock()
+	r.shadows[sess.UID] = sh
+	r.mu.Unlock()
+
+	return sh
+}
+
+func (r *shadowRegistry) unregister(sh *shadow) {
+	r.mu.Lock()
+	if r.shadows[sh.session.UID] == sh {
+		delete(r.shadows, sh.session.UID)
+	}
+	r.mu.Unlock()
+
+	sh.close()
+}
+
+func (r *shadowRegistry) get(uid string) *shadow {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.shadows[uid]
+}
+
+// shadow tees the output of a pty session to the users watching it, letting
+// those allowed to type into it.
+type shadow struct {
+	session *Session
+	stdin   io.Writer
+
+	mu      sync.Mutex
+	viewers map[*viewer]struct{}
+	closed  bool
+}
+
+type viewer struct {
+	conn io.ReadWriteCloser
+	out  chan []byte
+}
+
+// Write sends the output of the session to the viewers.
+func (sh *shadow) Write(p []byte) (int, error) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	for v := range sh.viewers {
+		select {
+		case v.out <- append([]byte(nil), p...):
+		default:
+			// Never slow down the session because of a viewer
+			sh.remove(v)
+		}
+	}
+
+	return len(p), nil
+}
+
+// attach streams the session to conn until either of them is closed. Input
+// from the viewer is sent to the session only when write is set, otherwise
+// Ctrl-C detaches it.
+func (sh *shadow) attach(conn io.ReadWriteCloser, username string, write bool) error {
+	v := &viewer{conn: conn, out: make(chan []byte, shadowBacklog)}
+
+	sh.mu.Lock()
+	if sh.closed {
+		sh.mu.Unlock()
+		return ErrShadowClosed
+	}
+	sh.viewers[v] = struct{}{}
+	sh.mu.Unlock()
+
+	mode := "watching"
+	if write {
+		mode = "sharing"
+	}
+
+	sh.session.notify(fmt.Sprintf("*** %s is %s this session ***", username, mode))
+
+	logrus.WithFields(logrus.Fields{
+		"session":  sh.session.UID,
+		"username": username,
+		"write":    write,
+	}).Info("Viewer attached to session")
+
+	go func() {
+		for p := range v.out {
+			if _, err := conn.Write(p); err != nil {
+				break
+			}
+		}
+
+		conn.Close()
+	}()
+
+	buf := make([]byte, 1024)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			if write {
+				sh.stdin.Write(buf[:n]) // nolint:errcheck
+			} else if bytes.IndexByte(buf[:n], 0x03) >= 0 {
+				break
+			}
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	sh.mu.Lock()
+	sh.remove(v)
+	closed := sh.closed
+	sh.mu.Unlock()
+
+	if !closed {
+		sh.session.notify(fmt.Sprintf("*** %s stopped %s this session ***", username, mode))
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"session":  sh.session.UID,
+		"username": username,
+	}).Info("Viewer detached from session")
+
+	return nil
+}
+
+// remove detaches a viewer, which must be done holding the lock.
+func (sh *shadow) remove(v *viewer) {
+	if _, ok := sh.viewers[v]; ok {
+		delete(sh.viewers, v)
+		close(v.out)
+	}
+}
+
+func (sh *shadow) close() {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.closed = true
+	for v := range sh.viewers {
+		sh.remove(v)
+	}
+}
+
+// shadowUpgrader keeps the default check of the Origin header against the
+// host of the request, as the web terminal is authenticated by cookie and
+// other sites must not attach to the sessions on behalf of the user.
+var shadowUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{"binary"},
+}
+
+// ShadowHandler attaches web terminals to the sessions, forwarding the
+// requests to the instance running the session when it's not this one.
+type ShadowHandler struct {
+	instance string
+	client   api.Client
+}
+
+func NewShadowHandler(instance string, client api.Client) *ShadowHandler {
+	return &ShadowHandler{instance: instance, client: client}
+}
+
+func (h *ShadowHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	tenant := req.Header.Get("X-Tenant-ID")
+	username := req.Header.Get("X-Username")
+	if tenant == "" || username == "" {
+		http.Error(res, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	uid := req.URL.Query().Get("session")
+	write := req.URL.Query().Get("write") == "true"
+
+	sh := shadows.get(uid)
+	if sh == nil {
+		h.forward(res, req, uid)
+		return
+	}
+
+	session, err := h.client.AuthorizeShadow(uid, username, write)
+	if err != nil || session.TenantID != tenant {
+		http.Error(res, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	ws, err := shadowUpgrader.Upgrade(res, req, nil)
+	if err != nil {
+		return
+	}
+
+	conn := wsconnadapter.New(ws)
+
+	if err := sh.attach(conn, username, write); err != nil {
+		conn.Close()
+	}
+}
+
+// forward proxies the request to the instance running the session.
+func (h *ShadowHandler) forward(res http.ResponseWriter, req *http.Request, uid string) {
+	session, err := h.client.GetSession(uid)
+	if err != nil || !session.Active || session.Instance == "" || session.Instance == h.instance {
+		http.Error(res, "session not found", http.StatusNotFound)
+		return
+	}
+
+	httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: session.Instance}).ServeHTTP(res, req)
+}
+
+// parseShadowTarget parses the SSH targets of the users attaching to active
+// sessions, reporting false for the other targets.
+func parseShadowTarget(target string) (username, uid string, write, ok bool) {
+	parts := strings.SplitN(target, "@", 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], shadowPrefix) {
+		return "", "", false, false
+	}
+
+	username = parts[0]
+	uid = strings.TrimPrefix(parts[1], shadowPrefix)
+
+	if strings.HasSuffix(uid, ":rw") {
+		uid = strings.TrimSuffix(uid, ":rw")
+		write = true
+	}
+
+	return username, uid, write, username != "" && uid != ""
+}
+
+// shadowHandler attaches an SSH client to an active session. The user is
+// authenticated with the password of their account.
+func (s *Server) shadowHandler(session sshserver.Session, username, uid string, write bool) {
+	defer session.Close()
+
+	passwd, _ := session.Context().Value("password").(string)
+	if passwd == "" || !checkUserPassword(username, passwd) {
+		io.WriteString(session, "Permission denied\n") // nolint:errcheck
+		return
+	}
+
+	apiClient := api.NewClient()
+
+	if sh := shadows.get(uid); sh != nil {
+		if _, err := apiClient.AuthorizeShadow(uid, username, write); err != nil {
+			io.WriteString(session, "Permission denied\n") // nolint:errcheck
+			return
+		}
+
+		if err := sh.attach(session, username, write); err != nil {
+			io.WriteString(session, fmt.Sprintf("%s\n", err)) // nolint:errcheck
+		}
+
+		return
+	}
+
+	// Attach through the web terminal handler of the instance running it
+	remote, err := apiClient.GetSession(uid)
+	if err != nil || !remote.Active || remote.Instance == "" || remote.Instance == s.tunnel.Instance {
+		io.WriteString(session, "Session not found\n") // nolint:errcheck
+		return
+	}
+
+	u := url.URL{Scheme: "ws", Host: remote.Instance, Path: ShadowURL}
+	u.RawQuery = url.Values{"session": {uid}, "write": {fmt.Sprint(write)}}.Encode()
+
+	header := http.Header{}
+	header.Set("X-Tenant-ID", remote.TenantID)
+	header.Set("X-Username", username)
+
+	ws, res, err := websocket.DefaultDialer.Dial(u.String(), header)
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusForbidden {
+			io.WriteString(session, "Permission denied\n") // nolint:errcheck
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"session":  uid,
+			"instance": remote.Instance,
+			"err":      err,
+		}).Error("Failed to attach to session on other instance")
+
+		return
+	}
+
+	conn := wsconnadapter.New(ws)
+
+	go func() {
+		io.Copy(conn, session) // nolint:errcheck
+		conn.Close()
+	}()
+
+	io.Copy(session, conn) // nolint:errcheck
+	conn.Close()
+}
+
+// checkUserPassword authenticates a user of the server.
+func checkUserPassword(username, password string) bool {
+	res, _, errs := gorequest.New().Post("http://api:8080/api/login").
+		Send(models.UserAuthRequest{Username: username, Password: password}).End()
+
+	return len(errs) == 0 && res.StatusCode == http.StatusOK
+}
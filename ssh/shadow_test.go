@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowUpgraderOrigin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ws, err := shadowUpgrader.Upgrade(res, req, nil)
+		if err != nil {
+			return
+		}
+
+		ws.Close()
+	}))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	cases := []struct {
+		name   string
+		origin string
+		status int
+	}{
+		{"same origin", server.URL, http.StatusSwitchingProtocols},
+		{"no origin", "", http.StatusSwitchingProtocols},
+		{"other site", "http://evil.example.com", http.StatusForbidden},
+		{"device page", "http://80-device.namespace.devices.example.com", http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			if tc.origin != "" {
+				header.Set("Origin", tc.origin)
+			}
+
+			ws, res, _ := websocket.DefaultDialer.Dial(url, header)
+			if ws != nil {
+				ws.Close()
+			}
+
+			assert.Equal(t, tc.status, res.StatusCode)
+		})
+	}
+}
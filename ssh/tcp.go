@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	api "github.com/shellhub-io/shellhub/pkg/api/client"
+	"github.com/shellhub-io/shellhub/pkg/httptunnel"
+	"github.com/shellhub-io/shellhub/pkg/models"
+	"github.com/shellhub-io/shellhub/pkg/wsconnadapter"
+	"github.com/sirupsen/logrus"
+)
+
+// TCPURL is where the clients open TCP connections to the ports of the
+// devices, e.g. /ws/tcp?device=<namespace>.<name>&port=5432. The device may
+// also be given by its UID.
+const TCPURL = "/ws/tcp"
+
+var tcpUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{"binary"},
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// TCPHandler carries raw TCP streams between websocket clients and the ports
+// of the devices through their tunnel. The requests must be authenticated by
+// the gateway in front of it, which sets the tenant of the user in the
+// X-Tenant-ID header.
+type TCPHandler struct {
+	tunnel *httptunnel.Tunnel
+	client api.Client
+}
+
+func NewTCPHandler(tunnel *httptunnel.Tunnel, client api.Client) *TCPHandler {
+	return &TCPHandler{tunnel: tunnel, client: client}
+}
+
+func (h *TCPHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	tenant := req.Header.Get("X-Tenant-ID")
+	if tenant == "" {
+		http.Error(res, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	port := req.URL.Query().Get("port")
+	if !validPort(port) {
+		http.Error(res, "invalid port", http.StatusBadRequest)
+		return
+	}
+
+	device, err := h.lookupDevice(req.URL.Query().Get("device"))
+	if err != nil || device.TenantID != tenant || device.Status != "accepted" {
+		http.Error(res, "device not found", http.StatusNotFound)
+		return
+	}
+
+	conn, err := h.dialPort(req.Context(), device.UID, port)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"uid":  device.UID,
+			"port": port,
+			"err":  err,
+		}).Error("Failed to connect to device port")
+
+		http.Error(res, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	ws, err := tcpUpgrader.Upgrade(res, req, nil)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	client := wsconnadapter.New(ws)
+
+	go func() {
+		io.Copy(conn, client) // nolint:errcheck
+		conn.Close()
+	}()
+
+	io.Copy(client, conn) // nolint:errcheck
+	client.Close()
+}
+
+// lookupDevice finds the device either by <namespace>.<name> or by UID.
+func (h *TCPHandler) lookupDevice(device string) (*models.Device, error) {
+	if parts := strings.SplitN(device, ".", 2); len(parts) == 2 {
+		return h.client.LookupDevice(parts[0], parts[1])
+	}
+
+	return h.client.GetDevice(device)
+}
+
+// dialPort opens a connection to the port of the device, which carries the
+// raw stream once the agent switches protocols.
+func (h *TCPHandler) dialPort(ctx context.Context, uid, port string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, proxyDialTimeout)
+	defer cancel()
+
+	conn, err := dialDevice(ctx, h.tunnel, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/tcp/%s", port), nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("device refused connection: %s", resp.Status)
+	}
+
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn reads from the reader used to parse the response of the
+// agent, which may already hold data sent by the service.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}